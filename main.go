@@ -1,124 +1,200 @@
+// Command pocket2fedi posts new Pocket saves to a fediverse account. The
+// work is done by package syndicate; this wrapper handles flags, signals and
+// the exit code.
 package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"time"
+	"os/signal"
+	"syscall"
 
-	"github.com/mattn/go-mastodon"
-	"github.com/motemen/go-pocket/api"
-	"github.com/motemen/go-pocket/auth"
-	"golang.org/x/oauth2"
+	"github.com/nate-johnston/pocket2fedi/syndicate"
 )
 
-// Configuration struct to hold API keys and tokens
-type Config struct {
-	PocketConsumerKey string
-	PocketAccessToken string
-	MastodonServer    string
-	MastodonToken     string
-}
-
-// PocketItem represents a simplified Pocket item structure
-type PocketItem struct {
-	Title string
-	URL   string
+func main() {
+	os.Exit(realMain())
 }
 
-// loadConfigFromEnv loads configuration from environment variables
-func loadConfigFromEnv() (*Config, error) {
-	config := &Config{
-		PocketConsumerKey: os.Getenv("POCKET_CONSUMER_KEY"),
-		PocketAccessToken: os.Getenv("POCKET_ACCESS_TOKEN"),
-		MastodonServer:    os.Getenv("MASTODON_SERVER"),
-		MastodonToken:     os.Getenv("MASTODON_TOKEN"),
+// realMain runs the command and returns its exit code, so that deferred calls
+// run before main exits
+func realMain() int {
+	if len(os.Args) > 1 && os.Args[1] == "authorize" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := syndicate.RunAuthorize(ctx, os.Args[2:]); err != nil {
+			log.Printf("Error authorizing with Pocket: %v", err)
+			return syndicate.ExitFailure
+		}
+		return syndicate.ExitSuccess
 	}
-
-	if config.PocketConsumerKey == "" || config.PocketAccessToken == "" || config.MastodonServer == "" || config.MastodonToken == "" {
-		return nil, fmt.Errorf("missing required environment variables")
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ok, err := syndicate.RunCheck(ctx, os.Args[2:], os.Stdout)
+		if err != nil {
+			log.Printf("Error checking credentials: %v", err)
+			return syndicate.ExitFailure
+		}
+		if !ok {
+			return syndicate.ExitFailure
+		}
+		return syndicate.ExitSuccess
 	}
 
-	return config, nil
-}
-
-// getRecentPocketSaves fetches recent Pocket saves
-func getRecentPocketSaves(ctx context.Context, consumerKey, accessToken string) ([]*PocketItem, error) {
-	client, err := api.NewClient(&oauth2.Config{}, &oauth2.Token{AccessToken: accessToken})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Pocket client: %w", err)
+	countOnly := flag.Bool("count-only", false, "print the number of new items that would be posted and exit")
+	dumpItems := flag.Bool("dump-items", false, "print the fetched items as JSON and exit without posting")
+	explain := flag.Bool("explain-config", false, "print each effective config value with its source and exit")
+	nothingNewCode := flag.Int("nothing-new-code", syndicate.ExitSuccess, "exit code to use when there was nothing new to post")
+	interactive := flag.Bool("interactive", false, "show each status and ask before posting it")
+	configFile := flag.String("config", "", "load settings from this YAML or TOML file; environment variables override it")
+	dryRun := flag.Bool("dry-run", false, "log the statuses that would be posted without posting them")
+	healthOnce := flag.Bool("health-once", false, "check that every dependency is reachable, report each, and exit")
+	sinceDays := flag.Int("since-days", -1, "only post saves added in the last N days, overriding POCKET_SINCE_DAYS")
+	limit := flag.Int("limit", -1, "post at most N saves this run, overriding POCKET2FEDI_MAX_POSTS")
+	daemon := flag.Bool("daemon", false, "keep running, fetching and posting every POLL_INTERVAL until stopped")
+	verbose := flag.Bool("v", false, "log run summaries as well as errors, overriding LOG_LEVEL")
+	veryVerbose := flag.Bool("vv", false, "also log each item's progress, overriding LOG_LEVEL")
+	flag.Parse()
+
+	if *nothingNewCode < 0 || *nothingNewCode > 125 || *nothingNewCode == syndicate.ExitFailure {
+		log.Printf("Invalid -nothing-new-code %d: must be between 0 and 125 and not %d", *nothingNewCode, syndicate.ExitFailure)
+		return syndicate.ExitFailure
 	}
 
-	params := &api.RetrieveInput{
-		Count:      10, // Fetch the 10 most recent items, adjust as needed
-		Sort:       api.SortNewest,
-		DetailType: api.DetailSimple,
+	var config *syndicate.Config
+	var err error
+	if *configFile != "" {
+		config, err = syndicate.LoadConfigFromFile(*configFile)
+	} else {
+		config, err = syndicate.LoadConfigFromEnv()
 	}
-
-	output, err := client.Retrieve(ctx, consumerKey, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve Pocket items: %w", err)
+		log.Printf("Error loading configuration:\n%v", err)
+		return syndicate.ExitFailure
+	}
+	if *dryRun {
+		config.DryRun = true
+		config.Sources["DryRun"] = "flag -dry-run"
+	}
+	if *sinceDays >= 0 {
+		config.SinceDays = *sinceDays
+		config.Sources["SinceDays"] = "flag -since-days"
+	}
+	if *limit >= 0 {
+		config.MaxPostsPerRun = *limit
+		config.Sources["MaxPostsPerRun"] = "flag -limit"
+	}
+	switch {
+	case *veryVerbose:
+		config.LogLevel = "debug"
+		config.Sources["LogLevel"] = "flag -vv"
+	case *verbose:
+		config.LogLevel = "info"
+		config.Sources["LogLevel"] = "flag -v"
+	case config.DryRun && config.Sources["LogLevel"] == "default":
+		// A dry run reports what it would post in info lines
+		config.LogLevel = "info"
+		config.Sources["LogLevel"] = "default for a dry run"
 	}
 
-	var recentSaves []*PocketItem
-	for _, item := range output.List {
-		if item.Status == 0 { // Unarchived items
-			recentSaves = append(recentSaves, &PocketItem{
-				Title: item.ResolvedTitle,
-				URL:   item.ResolvedURL,
-			})
-		}
+	if *explain {
+		syndicate.ExplainConfig(os.Stdout, config)
+		return syndicate.ExitSuccess
 	}
 
-	log.Printf("Successfully retrieved %d recent Pocket saves", len(recentSaves))
-	return recentSaves, nil
-}
+	syndicate.SetupLogging(config.LogFormat, config.LogLevel, os.Stderr)
+	syndicate.ConfigureHTTPClients(config)
 
-// postToMastodon posts a status to Mastodon
-func postToMastodon(ctx context.Context, server, accessToken, status string) error {
-	client := mastodon.NewClient(&mastodon.Config{
-		Server:      server,
-		AccessToken: accessToken,
-		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
-	})
+	// Cancel in-flight requests and stop between items on Ctrl-C or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	_, err := client.PostStatus(ctx, &mastodon.Status{
-		Status: status,
-	})
+	if *healthOnce {
+		if !syndicate.CheckDependencies(ctx, os.Stdout, config) {
+			return syndicate.ExitFailure
+		}
+		return syndicate.ExitSuccess
+	}
 
+	source, err := syndicate.NewPocketSource(config)
 	if err != nil {
-		return fmt.Errorf("failed to post to Mastodon: %w", err)
+		log.Printf("Error creating fetcher: %v", err)
+		return syndicate.ExitFailure
 	}
 
-	log.Printf("Successfully posted to Mastodon: %s", status)
-	return nil
-}
+	if *dumpItems {
+		if err := syndicate.DumpItems(ctx, source, os.Stdout); err != nil {
+			log.Printf("Error dumping items: %v", err)
+			return syndicate.ExitFailure
+		}
+		return syndicate.ExitSuccess
+	}
 
-func main() {
-	config, err := loadConfigFromEnv()
+	store, err := syndicate.OpenStateStore(config)
 	if err != nil {
-		log.Fatalf("Error loading configuration: %v", err)
+		log.Printf("Error opening state store: %v", err)
+		return syndicate.ExitFailure
 	}
 
-	ctx := context.Background()
+	if *countOnly {
+		count, err := syndicate.CountNewItems(ctx, config, source, store)
+		if err != nil {
+			log.Printf("Error counting Pocket saves: %v", err)
+			return syndicate.ExitFailure
+		}
+		fmt.Println(count)
+		syndicate.CloseStateStore(store)
+		return syndicate.ExitSuccess
+	}
 
-	recentSaves, err := getRecentPocketSaves(ctx, config.PocketConsumerKey, config.PocketAccessToken)
-	if err != nil {
-		log.Printf("Error fetching Pocket saves: %v", err)
-		return
+	if *interactive && *daemon {
+		log.Printf("-interactive can't be used with -daemon")
+		return syndicate.ExitFailure
+	}
+	if *interactive && !syndicate.IsTerminal(os.Stdin) {
+		log.Printf("-interactive needs a terminal on stdin; use -dry-run to preview what would be posted instead")
+		return syndicate.ExitFailure
 	}
 
-	for _, save := range recentSaves {
-		status := fmt.Sprintf("New Pocket save: %s - %s", save.Title, save.URL)
-		err := postToMastodon(ctx, config.MastodonServer, config.MastodonToken, status)
+	var metricsServer *http.Server
+	if config.MetricsAddr != "" {
+		metricsServer, err = syndicate.ServeMetrics(config.MetricsAddr)
 		if err != nil {
-			log.Printf("Error posting to Mastodon for '%s': %v", save.Title, err)
+			log.Printf("Error serving metrics: %v", err)
+			return syndicate.ExitFailure
 		}
-		// Add a small delay to avoid rate limiting
-		time.Sleep(2 * time.Second)
 	}
 
-	log.Println("Finished processing recent Pocket saves.")
+	if *daemon {
+		err := syndicate.RunDaemon(ctx, config, source, syndicate.NewPoster, store)
+		syndicate.CloseStateStore(store)
+		if err != nil {
+			log.Printf("Error running as a daemon: %v", err)
+			return syndicate.ExitFailure
+		}
+		return syndicate.ExitSuccess
+	}
+
+	poster, err := syndicate.NewPoster(config)
+	if err != nil {
+		log.Printf("Error creating poster: %v", err)
+		return syndicate.ExitFailure
+	}
+
+	var result syndicate.Result
+	if *interactive {
+		result = syndicate.RunInteractive(ctx, config, source, poster, store, os.Stdin, os.Stdout)
+	} else {
+		result = syndicate.Run(ctx, config, source, poster, store)
+	}
+	syndicate.CloseStateStore(store)
+	if metricsServer != nil {
+		syndicate.DrainMetrics(ctx, metricsServer, config.MetricsDrain)
+	}
+	return result.ExitCode(*nothingNewCode)
 }
@@ -0,0 +1,102 @@
+package syndicate
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/motemen/go-pocket/auth"
+)
+
+// authorizeTimeout is how long to wait for the user to approve access in
+// their browser
+var authorizeTimeout = 5 * time.Minute
+
+// RunAuthorize implements the authorize subcommand: it obtains a Pocket
+// access token for the consumer key and prints it to stdout
+func RunAuthorize(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("authorize", flag.ExitOnError)
+	consumerKey := flags.String("consumer-key", os.Getenv("POCKET_CONSUMER_KEY"), "Pocket consumer key (default $POCKET_CONSUMER_KEY)")
+	listen := flags.String("listen", "127.0.0.1:0", "address for the local server that catches Pocket's redirect")
+	flags.Parse(args)
+
+	if *consumerKey == "" {
+		return fmt.Errorf("missing Pocket consumer key: pass -consumer-key or set POCKET_CONSUMER_KEY")
+	}
+
+	// Reach Pocket through the same proxy and timeout as a normal run
+	httpConfig, err := httpConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	ConfigureHTTPClients(httpConfig)
+
+	ctx, cancel := context.WithTimeout(ctx, authorizeTimeout)
+	defer cancel()
+
+	authorization, err := authorizePocket(ctx, *consumerKey, *listen, func(authURL string) {
+		fmt.Fprintf(os.Stderr, "Open this URL in your browser to let pocket2fedi read your Pocket saves:\n\n  %s\n\n", authURL)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Authorized Pocket user %s; set POCKET_ACCESS_TOKEN to the token below", authorization.Username)
+	fmt.Println(authorization.AccessToken)
+	return nil
+}
+
+// authorizePocket runs Pocket's OAuth flow: it obtains a request token, calls
+// showURL with the authorization URL for the user to open, waits for Pocket to
+// redirect the browser back to a temporary server on listenAddr, and then
+// exchanges the request token for an access token
+func authorizePocket(ctx context.Context, consumerKey, listenAddr string, showURL func(string)) (*auth.Authorization, error) {
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start callback server: %w", err)
+	}
+
+	callback := make(chan struct{}, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "pocket2fedi is authorized. You can close this window.")
+		select {
+		case callback <- struct{}{}:
+		default:
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	redirectURL := fmt.Sprintf("http://%s/callback", listener.Addr())
+	requestToken, err := auth.ObtainRequestToken(consumerKey, redirectURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain Pocket request token: %w", err)
+	}
+
+	showURL(auth.GenerateAuthorizationURL(requestToken, redirectURL))
+
+	select {
+	case <-callback:
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("timed out waiting for authorization in the browser")
+		}
+		return nil, ctx.Err()
+	}
+
+	authorization, err := auth.ObtainAccessToken(consumerKey, requestToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain Pocket access token: %w", err)
+	}
+	return authorization, nil
+}
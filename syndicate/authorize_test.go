@@ -0,0 +1,114 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/motemen/go-pocket/api"
+)
+
+// mockPocketAuthServer answers Pocket's OAuth endpoints, recording the
+// request bodies it was sent
+func mockPocketAuthServer(t *testing.T, requests map[string]map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		requests[r.URL.Path] = body
+
+		switch r.URL.Path {
+		case "/v3/oauth/request":
+			w.Write([]byte(`{"code": "test_request_token"}`))
+		case "/v3/oauth/authorize":
+			if body["code"] != "test_request_token" {
+				w.Header().Set("X-Error", "Invalid request token")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"access_token": "test_access_token", "username": "pocketuser"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	originalEndpoint := api.Origin
+	api.Origin = server.URL
+	t.Cleanup(func() { api.Origin = originalEndpoint })
+	return server
+}
+
+func TestAuthorizePocket_Success(t *testing.T) {
+	requests := make(map[string]map[string]string)
+	mockPocketAuthServer(t, requests)
+
+	var authURL string
+	authorization, err := authorizePocket(context.Background(), "test_consumer_key", "127.0.0.1:0", func(u string) {
+		authURL = u
+		// Stand in for the browser following Pocket's redirect back to us
+		parsed, _ := url.Parse(u)
+		go http.Get(parsed.Query().Get("redirect_uri"))
+	})
+	if err != nil {
+		t.Fatalf("authorizePocket failed: %v", err)
+	}
+
+	if authorization.AccessToken != "test_access_token" || authorization.Username != "pocketuser" {
+		t.Errorf("Expected access token 'test_access_token' for 'pocketuser', got %+v", authorization)
+	}
+	if !strings.Contains(authURL, "request_token=test_request_token") {
+		t.Errorf("Expected the authorization URL to carry the request token, got '%s'", authURL)
+	}
+
+	request := requests["/v3/oauth/request"]
+	if request["consumer_key"] != "test_consumer_key" || !strings.HasSuffix(request["redirect_uri"], "/callback") {
+		t.Errorf("Expected a request token request with the consumer key and callback, got %v", request)
+	}
+	if exchange := requests["/v3/oauth/authorize"]; exchange["consumer_key"] != "test_consumer_key" || exchange["code"] != "test_request_token" {
+		t.Errorf("Expected the request token to be exchanged with the consumer key, got %v", exchange)
+	}
+}
+
+func TestAuthorizePocket_Timeout(t *testing.T) {
+	requests := make(map[string]map[string]string)
+	mockPocketAuthServer(t, requests)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// The user never approves access, so the callback never arrives
+	_, err := authorizePocket(ctx, "test_consumer_key", "127.0.0.1:0", func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	if _, ok := requests["/v3/oauth/authorize"]; ok {
+		t.Errorf("Expected no token exchange without authorization")
+	}
+}
+
+func TestAuthorizePocket_RequestTokenFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Error", "Invalid consumer key")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = server.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	shown := false
+	_, err := authorizePocket(context.Background(), "bad_consumer_key", "127.0.0.1:0", func(string) { shown = true })
+	if err == nil || !strings.Contains(err.Error(), "request token") {
+		t.Errorf("Expected a request token error, got %v", err)
+	}
+	if shown {
+		t.Errorf("Expected no authorization URL after a failed request token")
+	}
+}
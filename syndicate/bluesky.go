@@ -0,0 +1,216 @@
+package syndicate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// blueskyLimits are the limits for Bluesky posts, which can be at most 300
+// characters long
+var blueskyLimits = func() instanceLimits {
+	limits := defaultInstanceLimits
+	limits.MaxCharacters = 300
+	return limits
+}()
+
+// BlueskyPoster posts to a Bluesky account through its PDS (personal data
+// server) using an app password
+type BlueskyPoster struct {
+	server      string
+	handle      string
+	appPassword string
+	thread      bool
+
+	// Set once the session has been created; mu guards them for concurrent
+	// posts
+	mu        sync.Mutex
+	accessJwt string
+	did       string
+
+	// The first and latest posts of the thread, in thread mode
+	root   *blueskyStrongRef
+	parent *blueskyStrongRef
+}
+
+// blueskyStrongRef identifies a specific version of a record
+type blueskyStrongRef struct {
+	URI string `json:"uri"`
+	CID string `json:"cid"`
+}
+
+// blueskySession is the part of the createSession response we use
+type blueskySession struct {
+	AccessJwt string `json:"accessJwt"`
+	DID       string `json:"did"`
+}
+
+// blueskyPost is an app.bsky.feed.post record
+type blueskyPost struct {
+	Type      string         `json:"$type"`
+	Text      string         `json:"text"`
+	CreatedAt string         `json:"createdAt"`
+	Facets    []blueskyFacet `json:"facets,omitempty"`
+	Reply     *blueskyReply  `json:"reply,omitempty"`
+	Langs     []string       `json:"langs,omitempty"`
+}
+
+// blueskyReply links a post into a thread
+type blueskyReply struct {
+	Root   blueskyStrongRef `json:"root"`
+	Parent blueskyStrongRef `json:"parent"`
+}
+
+// blueskyFacet marks a byte range of the post text as a link
+type blueskyFacet struct {
+	Index struct {
+		ByteStart int `json:"byteStart"`
+		ByteEnd   int `json:"byteEnd"`
+	} `json:"index"`
+	Features []blueskyFeature `json:"features"`
+}
+
+// blueskyFeature is the link target of a facet
+type blueskyFeature struct {
+	Type string `json:"$type"`
+	URI  string `json:"uri"`
+}
+
+// blueskyError is the error body an XRPC endpoint returns
+type blueskyError struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// linkFacets returns a link facet for each URL in text. Bluesky doesn't
+// detect links itself, so without facets URLs aren't clickable. Offsets are
+// in bytes of the UTF-8 text.
+func linkFacets(text string) []blueskyFacet {
+	var facets []blueskyFacet
+	for _, loc := range statusURLPattern.FindAllStringIndex(text, -1) {
+		// Don't take in punctuation that closes the surrounding sentence,
+		// e.g. "(archived: https://...)"
+		link := strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?)'\"")
+		facet := blueskyFacet{Features: []blueskyFeature{{Type: "app.bsky.richtext.facet#link", URI: link}}}
+		facet.Index.ByteStart = loc[0]
+		facet.Index.ByteEnd = loc[0] + len(link)
+		facets = append(facets, facet)
+	}
+	return facets
+}
+
+// session returns the access token and DID of the account, signing in the
+// first time it is called
+func (p *BlueskyPoster) session(ctx context.Context) (accessJwt, did string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.accessJwt == "" {
+		var session blueskySession
+		err := p.xrpc(ctx, "com.atproto.server.createSession", "", map[string]string{
+			"identifier": p.handle,
+			"password":   p.appPassword,
+		}, &session)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create Bluesky session: %w", err)
+		}
+		p.accessJwt, p.did = session.AccessJwt, session.DID
+	}
+	return p.accessJwt, p.did, nil
+}
+
+// blueskyPostURL returns the bsky.app link for the post record uri, which
+// looks like at://<did>/app.bsky.feed.post/<rkey>
+func blueskyPostURL(did, uri string) string {
+	return "https://bsky.app/profile/" + did + "/post/" + path.Base(uri)
+}
+
+// Post posts status to Bluesky, creating a session first if needed
+func (p *BlueskyPoster) Post(ctx context.Context, status string) (string, error) {
+	return p.PostWithOptions(ctx, status, statusOptions{})
+}
+
+// PostWithOptions posts status tagged with the language in opts, if set.
+// Bluesky has no content warnings or polls, and images aren't attached.
+func (p *BlueskyPoster) PostWithOptions(ctx context.Context, status string, opts statusOptions) (string, error) {
+	accessJwt, did, err := p.session(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	post := blueskyPost{
+		Type:      "app.bsky.feed.post",
+		Text:      status,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Facets:    linkFacets(status),
+	}
+	if p.parent != nil {
+		post.Reply = &blueskyReply{Root: *p.root, Parent: *p.parent}
+	}
+	if opts.language != "" {
+		post.Langs = []string{opts.language}
+	}
+
+	var created blueskyStrongRef
+	err = p.xrpc(ctx, "com.atproto.repo.createRecord", accessJwt, map[string]any{
+		"repo":       did,
+		"collection": "app.bsky.feed.post",
+		"record":     post,
+	}, &created)
+	if err != nil {
+		return "", fmt.Errorf("failed to post to Bluesky: %w", err)
+	}
+
+	if p.thread {
+		if p.root == nil {
+			p.root = &created
+		}
+		p.parent = &created
+	}
+	return blueskyPostURL(did, created.URI), nil
+}
+
+// xrpc calls the procedure method on the PDS with body as JSON, decoding the
+// response into result. The access token is sent if one is given.
+func (p *BlueskyPoster) xrpc(ctx context.Context, method, accessToken string, body, result any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(p.server, "/") + "/xrpc/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	client := &http.Client{Timeout: httpTimeout, Transport: mastodonRateLimit}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr blueskyError
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s: %s: %s", resp.Status, apiErr.Error, apiErr.Message)
+		}
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
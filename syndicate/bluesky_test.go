@@ -0,0 +1,167 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// blueskyRecordRequest is a createRecord request as the mock PDS sees it
+type blueskyRecordRequest struct {
+	Repo       string      `json:"repo"`
+	Collection string      `json:"collection"`
+	Record     blueskyPost `json:"record"`
+}
+
+func TestBlueskyPoster_Post(t *testing.T) {
+	var sessions int
+	var records []blueskyRecordRequest
+	mockPDS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.server.createSession":
+			var login map[string]string
+			json.NewDecoder(r.Body).Decode(&login)
+			if login["identifier"] != "test.bsky.social" || login["password"] != "test-app-password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error": "AuthenticationRequired", "message": "Invalid identifier or password"}`))
+				return
+			}
+			sessions++
+			w.Write([]byte(`{"accessJwt": "test_access_jwt", "refreshJwt": "test_refresh_jwt", "did": "did:plc:test", "handle": "test.bsky.social"}`))
+		case "/xrpc/com.atproto.repo.createRecord":
+			if r.Header.Get("Authorization") != "Bearer test_access_jwt" {
+				t.Errorf("Expected the session's access token, got '%s'", r.Header.Get("Authorization"))
+			}
+			var req blueskyRecordRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			records = append(records, req)
+			fmt.Fprintf(w, `{"uri": "at://did:plc:test/app.bsky.feed.post/%d", "cid": "cid%d"}`, len(records), len(records))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockPDS.Close()
+
+	config := &Config{FediverseType: fediverseBluesky, BlueskyPDS: mockPDS.URL, BlueskyHandle: "test.bsky.social", BlueskyAppPassword: "test-app-password", ThreadMode: true}
+	poster, err := NewPoster(config)
+	if err != nil {
+		t.Fatalf("NewPoster failed: %v", err)
+	}
+
+	var urls []string
+	for _, status := range []string{"Café review - https://example.com/café", "Second - https://example.com/2", "Third - https://example.com/3"} {
+		statusURL, err := poster.Post(context.Background(), status)
+		if err != nil {
+			t.Fatalf("Post failed: %v", err)
+		}
+		urls = append(urls, statusURL)
+	}
+
+	if sessions != 1 {
+		t.Errorf("Expected the session to be created once and reused, got %d sessions", sessions)
+	}
+	if urls[0] != "https://bsky.app/profile/did:plc:test/post/1" {
+		t.Errorf("Expected the bsky.app link to the first post, got '%s'", urls[0])
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+
+	first := records[0]
+	if first.Repo != "did:plc:test" || first.Collection != "app.bsky.feed.post" || first.Record.Type != "app.bsky.feed.post" {
+		t.Errorf("Expected a post record in the account's repo, got %+v", first)
+	}
+	if first.Record.Text != "Café review - https://example.com/café" || first.Record.CreatedAt == "" {
+		t.Errorf("Expected the status text and a creation time, got %+v", first.Record)
+	}
+	if len(first.Record.Facets) != 1 {
+		t.Fatalf("Expected a link facet, got %+v", first.Record.Facets)
+	}
+	// Offsets are in bytes, so the é before the link shifts it by one
+	facet := first.Record.Facets[0]
+	if facet.Index.ByteStart != 15 || facet.Index.ByteEnd != 40 || facet.Features[0].URI != "https://example.com/café" {
+		t.Errorf("Expected a link facet over bytes 15-40, got %+v", facet)
+	}
+	if first.Record.Reply != nil {
+		t.Errorf("Expected the first post not to be a reply, got %+v", first.Record.Reply)
+	}
+
+	// Later posts reply to the previous one, all under the first
+	for i, parent := range []string{"cid1", "cid2"} {
+		reply := records[i+1].Record.Reply
+		if reply == nil || reply.Root.CID != "cid1" || reply.Parent.CID != parent {
+			t.Errorf("Post %d: expected a reply to %s in the thread under cid1, got %+v", i+2, parent, reply)
+		}
+	}
+}
+
+func TestBlueskyPoster_Language(t *testing.T) {
+	var records []blueskyRecordRequest
+	mockPDS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.server.createSession":
+			w.Write([]byte(`{"accessJwt": "test_access_jwt", "did": "did:plc:test"}`))
+		case "/xrpc/com.atproto.repo.createRecord":
+			var req blueskyRecordRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			records = append(records, req)
+			fmt.Fprintf(w, `{"uri": "at://did:plc:test/app.bsky.feed.post/%d", "cid": "cid%d"}`, len(records), len(records))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockPDS.Close()
+
+	poster := &BlueskyPoster{server: mockPDS.URL, handle: "test.bsky.social", appPassword: "test-app-password"}
+	for _, language := range []string{"de", ""} {
+		if _, err := postStatus(context.Background(), poster, "Test post - https://example.com/", statusOptions{language: language}); err != nil {
+			t.Fatalf("postStatus failed: %v", err)
+		}
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if langs := records[0].Record.Langs; len(langs) != 1 || langs[0] != "de" {
+		t.Errorf("Expected langs [de], got %v", langs)
+	}
+	if langs := records[1].Record.Langs; langs != nil {
+		t.Errorf("Expected no langs without a language, got %v", langs)
+	}
+}
+
+func TestBlueskyPoster_SessionFailure(t *testing.T) {
+	mockPDS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": "AuthenticationRequired", "message": "Invalid identifier or password"}`))
+	}))
+	defer mockPDS.Close()
+
+	poster := &BlueskyPoster{server: mockPDS.URL, handle: "test.bsky.social", appPassword: "wrong"}
+	_, err := poster.Post(context.Background(), "Test post")
+	if err == nil || !strings.Contains(err.Error(), "AuthenticationRequired") {
+		t.Errorf("Expected an authentication error, got %v", err)
+	}
+}
+
+func TestLinkFacets(t *testing.T) {
+	text := "Title - https://example.com/a (archived: https://web.archive.org/a)"
+	facets := linkFacets(text)
+	if len(facets) != 2 {
+		t.Fatalf("Expected 2 facets, got %+v", facets)
+	}
+	for i, expected := range []string{"https://example.com/a", "https://web.archive.org/a"} {
+		got := text[facets[i].Index.ByteStart:facets[i].Index.ByteEnd]
+		if got != expected || facets[i].Features[0].URI != expected {
+			t.Errorf("Facet %d: expected '%s', got '%s' (%s)", i, expected, got, facets[i].Features[0].URI)
+		}
+	}
+
+	if facets := linkFacets("No links here"); len(facets) != 0 {
+		t.Errorf("Expected no facets, got %+v", facets)
+	}
+}
@@ -0,0 +1,72 @@
+package syndicate
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters that only identify where a click came
+// from and never change the page
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+	"mkt_tok": true,
+}
+
+// isTrackingParam reports whether the query parameter name is a known
+// tracking parameter, including any utm_ parameter
+func isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, "utm_") || trackingParams[name]
+}
+
+// canonicalizeURL removes tracking query parameters from raw and strips any
+// trailing slash from its path. The remaining parameters keep their order and
+// encoding. URLs that don't parse are returned as-is.
+func canonicalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	if u.RawQuery != "" {
+		var kept []string
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			name, _, _ := strings.Cut(param, "=")
+			if unescaped, err := url.QueryUnescape(name); err == nil {
+				name = unescaped
+			}
+			if param != "" && !isTrackingParam(name) {
+				kept = append(kept, param)
+			}
+		}
+		u.RawQuery = strings.Join(kept, "&")
+		u.ForceQuery = false
+	}
+
+	if trimmed := strings.TrimRight(u.Path, "/"); trimmed != u.Path {
+		u.Path = trimmed
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+	}
+
+	return u.String()
+}
+
+// canonicalizeSaves rewrites the URL of each save to its canonical form
+func canonicalizeSaves(saves []*PocketItem) {
+	for _, save := range saves {
+		if canonical := canonicalizeURL(save.URL); canonical != save.URL {
+			logger.Debug(fmt.Sprintf("Rewrote '%s' to '%s'", save.URL, canonical), itemAttrs(save, "rewritten_url", canonical)...)
+			save.URL = canonical
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package syndicate
+
+import "testing"
+
+func TestCanonicalizeURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"https://example.com/story?utm_source=twitter&utm_medium=social&utm_campaign=launch", "https://example.com/story"},
+		{"https://example.com/story?id=42&fbclid=IwAR0abc", "https://example.com/story?id=42"},
+		{"https://example.com/search?gclid=xyz&q=go+generics&page=2&UTM_Content=ad", "https://example.com/search?q=go+generics&page=2"},
+		{"https://example.com/story/?ref=home&utm_source=rss#comments", "https://example.com/story?ref=home#comments"},
+		{"https://example.com/blog/post/", "https://example.com/blog/post"},
+		{"https://example.com/", "https://example.com"},
+		{"https://example.com/watch?v=dQw4w9WgXcQ", "https://example.com/watch?v=dQw4w9WgXcQ"},
+		// Nothing to strip, left untouched
+		{"https://example.com/story", "https://example.com/story"},
+		{"not a url", "not a url"},
+	}
+
+	for _, tt := range tests {
+		if got := canonicalizeURL(tt.input); got != tt.expected {
+			t.Errorf("canonicalizeURL(%q): expected '%s', got '%s'", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestCanonicalizeSaves(t *testing.T) {
+	saves := []*PocketItem{
+		{Title: "Tracked", URL: "https://example.com/story/?utm_source=pocket"},
+		{Title: "Clean", URL: "https://example.com/other?id=7"},
+	}
+
+	canonicalizeSaves(saves)
+
+	if saves[0].URL != "https://example.com/story" {
+		t.Errorf("Expected the tracking parameters to be stripped, got '%s'", saves[0].URL)
+	}
+	if saves[1].URL != "https://example.com/other?id=7" {
+		t.Errorf("Expected the clean URL to be unchanged, got '%s'", saves[1].URL)
+	}
+}
@@ -0,0 +1,80 @@
+package syndicate
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/mattn/go-mastodon"
+	"github.com/motemen/go-pocket/api"
+)
+
+// RunCheck implements the check subcommand: it makes one read-only,
+// authenticated call to each configured service, writes a line per service
+// to w, and reports whether all of them accepted the credentials
+func RunCheck(ctx context.Context, args []string, w io.Writer) (bool, error) {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	configFile := flags.String("config", "", "load settings from this YAML or TOML file; environment variables override it")
+	flags.Parse(args)
+
+	var config *Config
+	var err error
+	if *configFile != "" {
+		config, err = LoadConfigFromFile(*configFile)
+	} else {
+		config, err = LoadConfigFromEnv()
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load configuration:\n%w", err)
+	}
+
+	ConfigureHTTPClients(config)
+	checks := credentialChecks(config)
+	if len(checks) == 0 {
+		fmt.Fprintln(w, "No Pocket or Mastodon credentials to check with this configuration")
+	}
+	return checkHealth(ctx, w, checks), nil
+}
+
+// credentialChecks lists a minimal authenticated call for Pocket and
+// Mastodon, where they are configured
+func credentialChecks(config *Config) []dependencyCheck {
+	var checks []dependencyCheck
+	if config.Source == sourcePocket {
+		checks = append(checks, dependencyCheck{"pocket", func(ctx context.Context) error {
+			return checkPocketCredentials(config.PocketConsumerKey, config.PocketAccessToken)
+		}})
+	}
+	if config.Output == outputMastodon && (config.FediverseType == "" || config.FediverseType == fediverseMastodon) {
+		checks = append(checks, dependencyCheck{"mastodon", func(ctx context.Context) error {
+			return checkMastodonCredentials(ctx, config.MastodonServer, config.MastodonToken)
+		}})
+	}
+	return checks
+}
+
+// checkPocketCredentials retrieves a single save to confirm Pocket accepts
+// the consumer key and access token
+func checkPocketCredentials(consumerKey, accessToken string) error {
+	client := api.NewClient(consumerKey, accessToken)
+	if _, err := client.Retrieve(&api.RetrieveOption{Count: 1}); err != nil {
+		return fmt.Errorf("failed to retrieve from Pocket: %w", err)
+	}
+	return nil
+}
+
+// checkMastodonCredentials calls verify_credentials to confirm the instance
+// accepts the access token
+func checkMastodonCredentials(ctx context.Context, server, accessToken string) error {
+	client := mastodon.NewClient(&mastodon.Config{
+		Server:      server,
+		AccessToken: accessToken,
+	})
+	client.Timeout = httpTimeout
+	client.Transport = httpTransport
+	if _, err := client.GetAccountCurrentUser(ctx); err != nil {
+		return fmt.Errorf("failed to verify Mastodon credentials: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package syndicate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/motemen/go-pocket/api"
+)
+
+func TestCredentialChecks(t *testing.T) {
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			AccessToken string `json:"access_token"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.AccessToken != "test_access_token" {
+			w.Header().Set("X-Error", "Invalid access token")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": 1, "list": {}}`))
+	}))
+	defer mockPocketServer.Close()
+
+	var posts int
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+		}
+		if r.URL.Path != "/api/v1/accounts/verify_credentials" {
+			t.Errorf("Expected a verify_credentials request, got %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test_mastodon_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "The access token is invalid"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1", "username": "tester"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	tests := []struct {
+		name          string
+		pocketToken   string
+		mastodonToken string
+		healthy       bool
+		expected      []string
+	}{
+		{"both accepted", "test_access_token", "test_mastodon_token", true, []string{"pocket       ok", "mastodon     ok"}},
+		{"pocket rejected", "bad_access_token", "test_mastodon_token", false, []string{"pocket       FAIL", "Invalid access token", "mastodon     ok"}},
+		{"mastodon rejected", "test_access_token", "bad_mastodon_token", false, []string{"pocket       ok", "mastodon     FAIL", "401"}},
+	}
+
+	for _, tt := range tests {
+		config := &Config{
+			Source:            sourcePocket,
+			PocketConsumerKey: "test_consumer_key",
+			PocketAccessToken: tt.pocketToken,
+			Output:            outputMastodon,
+			MastodonServer:    mockMastodonServer.URL,
+			MastodonToken:     tt.mastodonToken,
+		}
+
+		var out bytes.Buffer
+		if healthy := checkHealth(context.Background(), &out, credentialChecks(config)); healthy != tt.healthy {
+			t.Errorf("%s: expected healthy %v, got %v", tt.name, tt.healthy, healthy)
+		}
+		for _, want := range tt.expected {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s: expected output to contain %q, got:\n%s", tt.name, want, out.String())
+			}
+		}
+	}
+
+	if posts != 0 {
+		t.Errorf("Expected nothing to be posted, got %d posts", posts)
+	}
+}
+
+func TestCredentialChecks_OnlyConfiguredServices(t *testing.T) {
+	checks := credentialChecks(&Config{Source: sourceWallabag, Output: outputMastodon, FediverseType: fediverseBluesky})
+	if len(checks) != 0 {
+		t.Errorf("Expected no credential checks for Wallabag and Bluesky, got %d", len(checks))
+	}
+}
@@ -0,0 +1,777 @@
+package syndicate
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mattn/go-mastodon"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration struct to hold API keys and tokens
+type Config struct {
+	Source               string
+	PocketConsumerKey    string
+	PocketAccessToken    string
+	Count                int
+	WallabagServer       string
+	WallabagClientID     string
+	WallabagClientSecret string
+	WallabagUsername     string
+	WallabagPassword     string
+	MastodonServer       string
+	MastodonToken        string
+	Visibility           string
+	WaybackMode          string
+	ImageItemPolicy      string
+	URLRegex             *regexp.Regexp
+	TitleRegex           *regexp.Regexp
+	TitleDenyRegex       *regexp.Regexp
+	HealthCheck          bool
+	EnrichConcurrency    int
+	EnrichHostDelay      time.Duration
+	EnrichJitter         time.Duration
+	Poll                 *mastodon.TootPoll
+	Deamp                bool
+	DeampConfirm         bool
+	FailureSummary       string
+	NormalizeUnicode     bool
+	OGFallback           bool
+	DeniedItemsFile      string
+	LongURLPolicy        string
+	LongURLPercent       int
+	ShortenerURL         string
+	Quarantine           time.Duration
+	URLSource            string
+	TitleSource          string
+	JSONLinesInput       string
+	Output               string
+	MinBatch             int
+	MinBatchMaxHold      time.Duration
+	StateFile            string
+	DryRun               bool
+	StatusTemplate       string
+	SpoilerText          string
+	LogFormat            string
+	LogLevel             string
+	ThreadMode           bool
+	HashtagsFromTags     bool
+	StatusSuffix         string
+	SinceDays            int
+	DomainBlocklist      []string
+	MaxExcerptLength     int
+	FediverseType        string
+	BlueskyPDS           string
+	BlueskyHandle        string
+	BlueskyAppPassword   string
+	Concurrency          int
+	CanonicalizeURL      bool
+	MaxPostsPerRun       int
+	FavoritesOnly        bool
+	TagAfterPost         string
+	ContentType          string
+	StateBackend         string
+	StateDBPath          string
+	MetricsAddr          string
+	MetricsDrain         time.Duration
+	DomainTemplates      map[string]string
+	DetectLanguage       bool
+	DefaultLanguage      string
+	PostOrder            string
+	HTTPTimeoutSeconds   int
+	Proxy                string
+	AttachImage          bool
+	Targets              []Target // more accounts to post to, from the config file only
+	PollInterval         time.Duration
+	PostDelay            time.Duration
+
+	// Sources records where each field's effective value came from, keyed
+	// by field name, for -explain-config. Poll's options and expiry are
+	// recorded as PollOptions and PollExpiry.
+	Sources map[string]string
+}
+
+// Target is an account, besides the one configured with MASTODON_SERVER and
+// MASTODON_TOKEN, that every save is also posted to
+type Target struct {
+	Type   string `yaml:"type" toml:"type"` // mastodon (default) or misskey
+	Server string `yaml:"server" toml:"server"`
+	Token  string `yaml:"token" toml:"token"`
+}
+
+// LoadConfigFromEnv loads configuration from environment variables and
+// validates it. Every problem found is reported together in the error.
+func LoadConfigFromEnv() (*Config, error) {
+	return loadConfig(func(key string) (string, string, bool) {
+		value, ok := os.LookupEnv(key)
+		return value, "env " + key, ok
+	}, nil)
+}
+
+// LoadConfigFromFile loads configuration from a YAML file, or a TOML file if
+// its name ends in .toml, whose keys are the environment variable names in
+// lower case, e.g. mastodon_server. Variables set in the environment override
+// the file, so secrets can stay out of it. The file may also list more
+// accounts to post to under targets.
+func LoadConfigFromFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var values map[string]string
+	var targets []Target
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		values, targets, err = parseTOMLConfig(path, data)
+	} else {
+		values, targets, err = parseYAMLConfig(path, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := loadConfig(func(key string) (string, string, bool) {
+		if value, ok := os.LookupEnv(key); ok {
+			return value, "env " + key, true
+		}
+		value, ok := values[strings.ToLower(key)]
+		return value, "file " + path, ok
+	}, targets)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) > 0 {
+		config.Sources["Targets"] = "file " + path
+	}
+	return config, nil
+}
+
+// parseYAMLConfig returns the settings and targets in a YAML config file
+func parseYAMLConfig(path string, data []byte) (map[string]string, []Target, error) {
+	var nodes map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &nodes); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	values := map[string]string{}
+	var targets []Target
+	for key, node := range nodes {
+		if key == "targets" {
+			if err := node.Decode(&targets); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse targets in config file %s: %w", path, err)
+			}
+			continue
+		}
+		var value string
+		if err := node.Decode(&value); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s in config file %s: %w", key, path, err)
+		}
+		values[key] = value
+	}
+	return values, targets, nil
+}
+
+// parseTOMLConfig returns the settings and targets in a TOML config file,
+// where targets is an array of tables. Numbers and booleans are read as the
+// strings the environment would hold.
+func parseTOMLConfig(path string, data []byte) (map[string]string, []Target, error) {
+	var fields map[string]toml.Primitive
+	meta, err := toml.Decode(string(data), &fields)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	values := map[string]string{}
+	var targets []Target
+	for key, field := range fields {
+		if key == "targets" {
+			if err := meta.PrimitiveDecode(field, &targets); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse targets in config file %s: %w", path, err)
+			}
+			continue
+		}
+		var value any
+		if err := meta.PrimitiveDecode(field, &value); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s in config file %s: %w", key, path, err)
+		}
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case int64:
+			values[key] = strconv.FormatInt(v, 10)
+		case float64:
+			values[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		default:
+			return nil, nil, fmt.Errorf("failed to parse %s in config file %s: must be a string, number or boolean", key, path)
+		}
+	}
+	return values, targets, nil
+}
+
+// configLookup returns the value of the setting named by an environment
+// variable, a description of where it was found, and whether it was set
+type configLookup func(key string) (value, source string, ok bool)
+
+// loadConfig builds and validates a Config from the settings lookup finds and
+// any extra targets
+func loadConfig(lookup configLookup, targets []Target) (*Config, error) {
+	var problems []error
+	sources := map[string]string{}
+	getenv := func(field, key string) string {
+		value, source, ok := lookup(key)
+		if ok {
+			sources[field] = source
+		}
+		return value
+	}
+	lookupValue := func(key string) string {
+		value, _, _ := lookup(key)
+		return value
+	}
+	getbool := func(field, key string, fallback bool) bool {
+		value := getenv(field, key)
+		if value == "" {
+			return fallback
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("invalid %s %q: must be true or false", key, value))
+			return fallback
+		}
+		return enabled
+	}
+	getint := func(field, key string, fallback int) int {
+		value := getenv(field, key)
+		if value == "" {
+			return fallback
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("invalid %s %q: must be an integer", key, value))
+			return fallback
+		}
+		return n
+	}
+	// getintOrDefault is getint for settings where a value that isn't a
+	// number means the default rather than a mistake to report
+	getintOrDefault := func(field, key string, fallback int) int {
+		n, err := strconv.Atoi(getenv(field, key))
+		if err != nil {
+			sources[field] = "default"
+			return fallback
+		}
+		return n
+	}
+	getduration := func(field, key string, fallback time.Duration) time.Duration {
+		value := getenv(field, key)
+		if value == "" {
+			return fallback
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("invalid %s %q: must be a duration such as 500ms or 2s", key, value))
+			return fallback
+		}
+		return d
+	}
+	getregex := func(field, key string) *regexp.Regexp {
+		pattern := getenv(field, key)
+		if pattern == "" {
+			return nil
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("invalid %s %q: %w", key, pattern, err))
+			return nil
+		}
+		return re
+	}
+	geturl := func(field, key string) string {
+		value := getenv(field, key)
+		if value == "" {
+			return ""
+		}
+		server, err := parseServerURL(value)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("invalid %s %q: %w", key, value, err))
+			return value
+		}
+		return server
+	}
+	// getsecret reads a secret from the file named by key_FILE if that is
+	// set, as with Docker and Kubernetes secrets, or else from key itself
+	getsecret := func(field, key string) string {
+		path, source, ok := lookup(key + "_FILE")
+		if !ok || path == "" {
+			return getenv(field, key)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("failed to read %s_FILE: %w", key, err))
+			return ""
+		}
+		sources[field] = source
+		return strings.TrimRight(string(data), " \t\r\n")
+	}
+	withDefault := func(field, value, fallback string) string {
+		if value == "" {
+			sources[field] = "default"
+			return fallback
+		}
+		return value
+	}
+
+	config := &Config{
+		Source:               withDefault("Source", getenv("Source", "POCKET2FEDI_SOURCE"), sourcePocket),
+		PocketConsumerKey:    getsecret("PocketConsumerKey", "POCKET_CONSUMER_KEY"),
+		PocketAccessToken:    getsecret("PocketAccessToken", "POCKET_ACCESS_TOKEN"),
+		Count:                getintOrDefault("Count", "POCKET_FETCH_COUNT", 10),
+		WallabagServer:       getenv("WallabagServer", "WALLABAG_SERVER"),
+		WallabagClientID:     getenv("WallabagClientID", "WALLABAG_CLIENT_ID"),
+		WallabagClientSecret: getsecret("WallabagClientSecret", "WALLABAG_CLIENT_SECRET"),
+		WallabagUsername:     getenv("WallabagUsername", "WALLABAG_USERNAME"),
+		WallabagPassword:     getsecret("WallabagPassword", "WALLABAG_PASSWORD"),
+		MastodonServer:       geturl("MastodonServer", "MASTODON_SERVER"),
+		MastodonToken:        getsecret("MastodonToken", "MASTODON_TOKEN"),
+		Visibility:           withDefault("Visibility", getenv("Visibility", "MASTODON_VISIBILITY"), mastodon.VisibilityPublic),
+		WaybackMode:          getenv("WaybackMode", "POCKET2FEDI_WAYBACK"),
+		ImageItemPolicy:      withDefault("ImageItemPolicy", getenv("ImageItemPolicy", "POCKET2FEDI_IMAGE_ITEMS"), imageItemsPost),
+		URLRegex:             getregex("URLRegex", "URL_REGEX"),
+		TitleRegex:           getregex("TitleRegex", "TITLE_REGEX"),
+		TitleDenyRegex:       getregex("TitleDenyRegex", "POCKET_TITLE_DENY_REGEX"),
+		HealthCheck:          getbool("HealthCheck", "MASTODON_HEALTH_CHECK", false),
+		EnrichConcurrency:    getint("EnrichConcurrency", "POCKET2FEDI_ENRICH_CONCURRENCY", 4),
+		EnrichHostDelay:      getduration("EnrichHostDelay", "POCKET2FEDI_ENRICH_HOST_DELAY", 0),
+		EnrichJitter:         getduration("EnrichJitter", "POCKET2FEDI_ENRICH_JITTER", 0),
+		Deamp:                getbool("Deamp", "DEAMP", false),
+		DeampConfirm:         getbool("DeampConfirm", "DEAMP_CONFIRM", false),
+		FailureSummary:       getenv("FailureSummary", "POCKET2FEDI_FAILURE_SUMMARY"),
+		NormalizeUnicode:     getbool("NormalizeUnicode", "POCKET2FEDI_NORMALIZE_UNICODE", true),
+		OGFallback:           getbool("OGFallback", "POCKET2FEDI_OG_FALLBACK", false),
+		DeniedItemsFile:      getenv("DeniedItemsFile", "POCKET2FEDI_DENIED_ITEMS"),
+		LongURLPolicy:        getenv("LongURLPolicy", "POCKET2FEDI_LONG_URLS"),
+		LongURLPercent:       getint("LongURLPercent", "POCKET2FEDI_LONG_URL_PERCENT", 50),
+		ShortenerURL:         getenv("ShortenerURL", "POCKET2FEDI_SHORTENER"),
+		Quarantine:           getduration("Quarantine", "QUARANTINE", 0),
+		URLSource:            withDefault("URLSource", getenv("URLSource", "POCKET2FEDI_URL_SOURCE"), urlSourceResolved),
+		TitleSource:          withDefault("TitleSource", getenv("TitleSource", "POCKET2FEDI_TITLE_SOURCE"), titleSourceAuto),
+		JSONLinesInput:       withDefault("JSONLinesInput", getenv("JSONLinesInput", "POCKET2FEDI_JSON_LINES"), "-"),
+		Output:               withDefault("Output", getenv("Output", "POCKET2FEDI_OUTPUT"), outputMastodon),
+		MinBatch:             getint("MinBatch", "MIN_BATCH", 0),
+		MinBatchMaxHold:      getduration("MinBatchMaxHold", "MIN_BATCH_MAX_HOLD", 0),
+		StateFile:            getenv("StateFile", "POCKET2FEDI_STATE_FILE"),
+		DryRun:               getbool("DryRun", "POCKET2FEDI_DRY_RUN", false),
+		StatusTemplate:       withDefault("StatusTemplate", getenv("StatusTemplate", "POCKET2FEDI_TEMPLATE"), defaultStatusTemplate),
+		SpoilerText:          getenv("SpoilerText", "MASTODON_SPOILER_TEXT"),
+		LogFormat:            withDefault("LogFormat", getenv("LogFormat", "LOG_FORMAT"), logFormatText),
+		LogLevel:             withDefault("LogLevel", strings.ToLower(getenv("LogLevel", "LOG_LEVEL")), logLevelError),
+		ThreadMode:           getbool("ThreadMode", "POCKET2FEDI_THREAD", false),
+		HashtagsFromTags:     getbool("HashtagsFromTags", "POCKET2FEDI_HASHTAGS", false),
+		StatusSuffix:         getenv("StatusSuffix", "POCKET2FEDI_STATUS_SUFFIX"),
+		SinceDays:            getint("SinceDays", "POCKET_SINCE_DAYS", 0),
+		DomainBlocklist:      parseDomainList(getenv("DomainBlocklist", "POCKET_DOMAIN_BLOCKLIST")),
+		MaxExcerptLength:     getint("MaxExcerptLength", "POCKET2FEDI_MAX_EXCERPT_LENGTH", 0),
+		FediverseType:        withDefault("FediverseType", getenv("FediverseType", "FEDIVERSE_TYPE"), fediverseMastodon),
+		BlueskyPDS:           withDefault("BlueskyPDS", getenv("BlueskyPDS", "BLUESKY_PDS"), "https://bsky.social"),
+		BlueskyHandle:        getenv("BlueskyHandle", "BLUESKY_HANDLE"),
+		BlueskyAppPassword:   getsecret("BlueskyAppPassword", "BLUESKY_APP_PASSWORD"),
+		Concurrency:          getint("Concurrency", "POCKET2FEDI_CONCURRENCY", 1),
+		CanonicalizeURL:      getbool("CanonicalizeURL", "POCKET2FEDI_CANONICALIZE_URLS", false),
+		MaxPostsPerRun:       getint("MaxPostsPerRun", "POCKET2FEDI_MAX_POSTS", 0),
+		FavoritesOnly:        getbool("FavoritesOnly", "POCKET_FAVORITES_ONLY", false),
+		TagAfterPost:         strings.TrimSpace(getenv("TagAfterPost", "POCKET_TAG_AFTER_POST")),
+		ContentType:          strings.ToLower(getenv("ContentType", "POCKET_CONTENT_TYPE")),
+		StateBackend:         withDefault("StateBackend", getenv("StateBackend", "STATE_BACKEND"), stateBackendFile),
+		StateDBPath:          getenv("StateDBPath", "STATE_DB_PATH"),
+		MetricsAddr:          getenv("MetricsAddr", "METRICS_ADDR"),
+		MetricsDrain:         getduration("MetricsDrain", "METRICS_DRAIN", 0),
+		DetectLanguage:       getbool("DetectLanguage", "POCKET2FEDI_DETECT_LANGUAGE", false),
+		DefaultLanguage:      getenv("DefaultLanguage", "DEFAULT_LANGUAGE"),
+		PostOrder:            withDefault("PostOrder", getenv("PostOrder", "POCKET2FEDI_POST_ORDER"), postOrderNewest),
+		HTTPTimeoutSeconds:   getint("HTTPTimeoutSeconds", "HTTP_TIMEOUT_SECONDS", defaultHTTPTimeoutSeconds),
+		Proxy:                getenv("Proxy", "POCKET2FEDI_PROXY"),
+		AttachImage:          getbool("AttachImage", "POCKET2FEDI_ATTACH_IMAGE", false),
+		PollInterval:         getduration("PollInterval", "POLL_INTERVAL", defaultPollInterval),
+		PostDelay:            getduration("PostDelay", "POST_DELAY", defaultPostDelay),
+		Sources:              sources,
+	}
+
+	for field, key := range map[string]string{
+		"Count":              "POCKET_FETCH_COUNT",
+		"EnrichConcurrency":  "POCKET2FEDI_ENRICH_CONCURRENCY",
+		"NormalizeUnicode":   "POCKET2FEDI_NORMALIZE_UNICODE",
+		"LongURLPercent":     "POCKET2FEDI_LONG_URL_PERCENT",
+		"HTTPTimeoutSeconds": "HTTP_TIMEOUT_SECONDS",
+		"PostDelay":          "POST_DELAY",
+		"PollInterval":       "POLL_INTERVAL",
+	} {
+		if lookupValue(key) == "" {
+			sources[field] = "default"
+		}
+	}
+
+	if value := getenv("DomainTemplates", "POCKET2FEDI_DOMAIN_TEMPLATES"); value != "" {
+		templates, err := parseDomainTemplates(value)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_DOMAIN_TEMPLATES: %w", err))
+		}
+		config.DomainTemplates = templates
+	}
+
+	for i, target := range targets {
+		if server, err := parseServerURL(target.Server); err == nil {
+			targets[i].Server = server
+		}
+	}
+	config.Targets = targets
+
+	if lookupValue("METRICS_DRAIN") == "" {
+		config.MetricsDrain = defaultMetricsDrain
+		sources["MetricsDrain"] = "default"
+	}
+
+	if getbool("Poll", "POCKET2FEDI_POLL", false) {
+		poll, err := parsePoll(getenv("PollOptions", "POCKET2FEDI_POLL_OPTIONS"), getenv("PollExpiry", "POCKET2FEDI_POLL_EXPIRY"))
+		if err != nil {
+			problems = append(problems, err)
+		}
+		config.Poll = poll
+	}
+
+	if err := config.Validate(); err != nil {
+		problems = append(problems, err)
+	}
+	if err := errors.Join(problems...); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// parseServerURL checks that raw is an http or https URL with a host and
+// returns it without a trailing slash
+func parseServerURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("must start with https:// or http://, e.g. https://mastodon.social")
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("must include a host name")
+	}
+	return strings.TrimRight(raw, "/"), nil
+}
+
+// Validate checks the configuration as a whole and returns an error listing
+// every problem found, rather than stopping at the first
+func (c *Config) Validate() error {
+	var problems []error
+
+	switch c.Source {
+	case sourcePocket:
+		if c.PocketConsumerKey == "" || c.PocketAccessToken == "" {
+			problems = append(problems, fmt.Errorf("missing required environment variables POCKET_CONSUMER_KEY and POCKET_ACCESS_TOKEN"))
+		}
+	case sourceWallabag:
+		if c.WallabagServer == "" || c.WallabagClientID == "" || c.WallabagClientSecret == "" || c.WallabagUsername == "" || c.WallabagPassword == "" {
+			problems = append(problems, fmt.Errorf("missing required Wallabag environment variables"))
+		}
+	case sourceJSONLines:
+	default:
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_SOURCE value %q (valid: %s, %s, %s)", c.Source, sourcePocket, sourceWallabag, sourceJSONLines))
+	}
+
+	switch c.Output {
+	case outputMastodon:
+		if c.FediverseType == fediverseBluesky {
+			if c.BlueskyHandle == "" || c.BlueskyAppPassword == "" {
+				problems = append(problems, fmt.Errorf("missing required environment variables BLUESKY_HANDLE and BLUESKY_APP_PASSWORD"))
+			}
+		} else if c.MastodonServer == "" || c.MastodonToken == "" {
+			problems = append(problems, fmt.Errorf("missing required environment variables MASTODON_SERVER and MASTODON_TOKEN"))
+		}
+	case outputJSON:
+	default:
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_OUTPUT value %q (valid: %s, %s)", c.Output, outputMastodon, outputJSON))
+	}
+
+	switch c.Visibility {
+	case mastodon.VisibilityPublic, mastodon.VisibilityUnlisted, mastodon.VisibilityFollowersOnly, mastodon.VisibilityDirectMessage:
+	default:
+		problems = append(problems, fmt.Errorf("invalid MASTODON_VISIBILITY value %q (valid: %s, %s, %s, %s)", c.Visibility, mastodon.VisibilityPublic, mastodon.VisibilityUnlisted, mastodon.VisibilityFollowersOnly, mastodon.VisibilityDirectMessage))
+	}
+
+	switch c.WaybackMode {
+	case "", waybackOriginal, waybackArchive, waybackBoth:
+	default:
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_WAYBACK value %q (valid: %s, %s, %s)", c.WaybackMode, waybackOriginal, waybackArchive, waybackBoth))
+	}
+
+	switch c.ImageItemPolicy {
+	case imageItemsPost, imageItemsSkip:
+	default:
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_IMAGE_ITEMS value %q (valid: %s, %s)", c.ImageItemPolicy, imageItemsPost, imageItemsSkip))
+	}
+
+	switch c.URLSource {
+	case urlSourceResolved, urlSourceGiven, urlSourceResolvedThenGiven:
+	default:
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_URL_SOURCE value %q (valid: %s, %s, %s)", c.URLSource, urlSourceResolved, urlSourceGiven, urlSourceResolvedThenGiven))
+	}
+
+	switch c.TitleSource {
+	case "", titleSourceAuto, titleSourceResolved, titleSourceGiven:
+	default:
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_TITLE_SOURCE value %q (valid: %s, %s, %s)", c.TitleSource, titleSourceAuto, titleSourceResolved, titleSourceGiven))
+	}
+
+	switch c.PostOrder {
+	case "", postOrderNewest, postOrderOldest:
+	default:
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_POST_ORDER value %q (valid: %s, %s)", c.PostOrder, postOrderNewest, postOrderOldest))
+	}
+
+	switch c.FediverseType {
+	case "", fediverseMastodon:
+	case fediverseMisskey, fediverseBluesky:
+		if c.Poll != nil {
+			problems = append(problems, fmt.Errorf("POCKET2FEDI_POLL is only supported with FEDIVERSE_TYPE=%s", fediverseMastodon))
+		}
+		if c.HealthCheck {
+			problems = append(problems, fmt.Errorf("MASTODON_HEALTH_CHECK is only supported with FEDIVERSE_TYPE=%s", fediverseMastodon))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("invalid FEDIVERSE_TYPE value %q (valid: %s, %s, %s)", c.FediverseType, fediverseMastodon, fediverseMisskey, fediverseBluesky))
+	}
+	if c.FediverseType == fediverseBluesky && c.FailureSummary != "" {
+		problems = append(problems, fmt.Errorf("POCKET2FEDI_FAILURE_SUMMARY is not supported with FEDIVERSE_TYPE=%s, which has no private posts", fediverseBluesky))
+	}
+	if c.FediverseType == fediverseBluesky && c.SpoilerText != "" {
+		problems = append(problems, fmt.Errorf("MASTODON_SPOILER_TEXT is not supported with FEDIVERSE_TYPE=%s, which has no content warnings", fediverseBluesky))
+	}
+	if c.FediverseType == fediverseBluesky && c.AttachImage {
+		problems = append(problems, fmt.Errorf("POCKET2FEDI_ATTACH_IMAGE is not supported with FEDIVERSE_TYPE=%s", fediverseBluesky))
+	}
+
+	switch c.LogFormat {
+	case "", logFormatText, logFormatJSON:
+	default:
+		problems = append(problems, fmt.Errorf("invalid LOG_FORMAT value %q (valid: %s, %s)", c.LogFormat, logFormatText, logFormatJSON))
+	}
+
+	switch c.LogLevel {
+	case "", logLevelDebug, logLevelInfo, logLevelWarn, logLevelError:
+	default:
+		problems = append(problems, fmt.Errorf("invalid LOG_LEVEL value %q (valid: %s, %s, %s, %s)", c.LogLevel, logLevelDebug, logLevelInfo, logLevelWarn, logLevelError))
+	}
+
+	switch c.FailureSummary {
+	case "", mastodon.VisibilityFollowersOnly, mastodon.VisibilityDirectMessage:
+	default:
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_FAILURE_SUMMARY value %q (valid: %s, %s)", c.FailureSummary, mastodon.VisibilityFollowersOnly, mastodon.VisibilityDirectMessage))
+	}
+
+	if (c.Source == sourcePocket || c.Source == sourceWallabag) && c.Count < 1 {
+		problems = append(problems, fmt.Errorf("invalid POCKET_FETCH_COUNT %d: must be a positive integer", c.Count))
+	}
+	if c.EnrichConcurrency < 1 {
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_ENRICH_CONCURRENCY %d: must be a positive integer", c.EnrichConcurrency))
+	}
+	if c.EnrichHostDelay < 0 {
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_ENRICH_HOST_DELAY %v: must not be negative", c.EnrichHostDelay))
+	}
+	if c.EnrichJitter < 0 {
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_ENRICH_JITTER %v: must not be negative", c.EnrichJitter))
+	}
+
+	switch c.LongURLPolicy {
+	case "", longURLsSkip:
+	case longURLsShorten:
+		if c.ShortenerURL == "" {
+			problems = append(problems, fmt.Errorf("POCKET2FEDI_LONG_URLS=%s requires POCKET2FEDI_SHORTENER", longURLsShorten))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_LONG_URLS value %q (valid: %s, %s)", c.LongURLPolicy, longURLsShorten, longURLsSkip))
+	}
+	if c.LongURLPolicy != "" && (c.LongURLPercent < 1 || c.LongURLPercent > 100) {
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_LONG_URL_PERCENT %d: must be between 1 and 100", c.LongURLPercent))
+	}
+
+	if c.MinBatch < 0 {
+		problems = append(problems, fmt.Errorf("invalid MIN_BATCH %d: must not be negative", c.MinBatch))
+	}
+	if c.MinBatchMaxHold < 0 {
+		problems = append(problems, fmt.Errorf("invalid MIN_BATCH_MAX_HOLD %v: must not be negative", c.MinBatchMaxHold))
+	}
+
+	if c.Quarantine < 0 {
+		problems = append(problems, fmt.Errorf("invalid QUARANTINE %v: must not be negative", c.Quarantine))
+	}
+	if c.Concurrency < 1 {
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_CONCURRENCY %d: must be a positive integer", c.Concurrency))
+	}
+	if c.Concurrency > 1 && c.ThreadMode {
+		problems = append(problems, fmt.Errorf("POCKET2FEDI_THREAD needs statuses posted in order, so POCKET2FEDI_CONCURRENCY must be 1"))
+	}
+	if c.MaxExcerptLength < 0 {
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_MAX_EXCERPT_LENGTH %d: must not be negative", c.MaxExcerptLength))
+	}
+	if c.SinceDays < 0 {
+		problems = append(problems, fmt.Errorf("invalid POCKET_SINCE_DAYS %d: must not be negative", c.SinceDays))
+	}
+	switch c.StateBackend {
+	case "", stateBackendFile:
+	case stateBackendSQLite:
+		if c.StateDBPath == "" {
+			problems = append(problems, fmt.Errorf("STATE_BACKEND=%s requires STATE_DB_PATH", stateBackendSQLite))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("invalid STATE_BACKEND value %q (valid: %s, %s)", c.StateBackend, stateBackendFile, stateBackendSQLite))
+	}
+
+	if c.FavoritesOnly && c.Source != sourcePocket {
+		problems = append(problems, fmt.Errorf("POCKET_FAVORITES_ONLY is only supported with POCKET2FEDI_SOURCE=%s", sourcePocket))
+	}
+	switch c.ContentType {
+	case "", contentTypeArticle, contentTypeVideo, contentTypeImage:
+	default:
+		problems = append(problems, fmt.Errorf("invalid POCKET_CONTENT_TYPE value %q (valid: %s, %s, %s)", c.ContentType, contentTypeArticle, contentTypeVideo, contentTypeImage))
+	}
+	if c.ContentType != "" && c.Source != sourcePocket {
+		problems = append(problems, fmt.Errorf("POCKET_CONTENT_TYPE is only supported with POCKET2FEDI_SOURCE=%s", sourcePocket))
+	}
+	if c.TagAfterPost != "" && c.Source != sourcePocket {
+		problems = append(problems, fmt.Errorf("POCKET_TAG_AFTER_POST is only supported with POCKET2FEDI_SOURCE=%s", sourcePocket))
+	}
+	if strings.Contains(c.TagAfterPost, ",") {
+		problems = append(problems, fmt.Errorf("invalid POCKET_TAG_AFTER_POST %q: must be a single tag without commas", c.TagAfterPost))
+	}
+	if c.DefaultLanguage != "" && !isLanguageCode(c.DefaultLanguage) {
+		problems = append(problems, fmt.Errorf("invalid DEFAULT_LANGUAGE %q: must be a two-letter ISO 639-1 code such as en", c.DefaultLanguage))
+	}
+	if c.MetricsDrain < 0 {
+		problems = append(problems, fmt.Errorf("invalid METRICS_DRAIN %v: must not be negative", c.MetricsDrain))
+	}
+	if c.HTTPTimeoutSeconds < 0 {
+		problems = append(problems, fmt.Errorf("invalid HTTP_TIMEOUT_SECONDS %d: must not be negative", c.HTTPTimeoutSeconds))
+	}
+	if c.Proxy != "" {
+		if _, err := parseProxyURL(c.Proxy); err != nil {
+			problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_PROXY: %w", err))
+		}
+	}
+	if c.AttachImage && c.Poll != nil {
+		problems = append(problems, fmt.Errorf("POCKET2FEDI_ATTACH_IMAGE can't be combined with POCKET2FEDI_POLL: Mastodon statuses can't have both"))
+	}
+	for i, target := range c.Targets {
+		if _, err := parseServerURL(target.Server); err != nil {
+			problems = append(problems, fmt.Errorf("invalid server %q for target %d: %w", target.Server, i+1, err))
+		}
+		if target.Token == "" {
+			problems = append(problems, fmt.Errorf("missing token for target %d (%s)", i+1, target.Server))
+		}
+		switch target.Type {
+		case "", fediverseMastodon, fediverseMisskey:
+		default:
+			problems = append(problems, fmt.Errorf("invalid type %q for target %d (valid: %s, %s)", target.Type, i+1, fediverseMastodon, fediverseMisskey))
+		}
+	}
+	// A zero interval only matters to -daemon, so it's only a mistake when
+	// POLL_INTERVAL was set to it
+	if c.PollInterval < 0 || (c.PollInterval == 0 && c.Sources["PollInterval"] != "" && c.Sources["PollInterval"] != "default") {
+		problems = append(problems, fmt.Errorf("invalid POLL_INTERVAL %v: must be positive", c.PollInterval))
+	}
+	if c.PostDelay < 0 {
+		problems = append(problems, fmt.Errorf("invalid POST_DELAY %v: must not be negative", c.PostDelay))
+	}
+	if c.MaxPostsPerRun < 0 {
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_MAX_POSTS %d: must not be negative", c.MaxPostsPerRun))
+	}
+
+	// Render a blank item so unknown fields are caught now rather than per item
+	if _, err := renderStatus(&PocketItem{}, c.StatusTemplate); err != nil {
+		problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_TEMPLATE: %w", err))
+	}
+	for domain, tmpl := range c.DomainTemplates {
+		if _, err := renderStatus(&PocketItem{}, tmpl); err != nil {
+			problems = append(problems, fmt.Errorf("invalid POCKET2FEDI_DOMAIN_TEMPLATES template for %s: %w", domain, err))
+		}
+	}
+
+	if c.DeampConfirm && !c.Deamp {
+		problems = append(problems, fmt.Errorf("DEAMP_CONFIRM requires DEAMP to be enabled"))
+	}
+
+	if c.Poll != nil {
+		problems = append(problems, validatePoll(c.Poll)...)
+	}
+
+	return errors.Join(problems...)
+}
+
+// Mastodon's default poll constraints
+const (
+	maxPollOptions      = 4
+	maxPollOptionLength = 50
+	minPollExpiry       = 5 * time.Minute
+	maxPollExpiry       = 30 * 24 * time.Hour
+)
+
+// parsePoll builds the poll attached to each status from a comma-separated
+// option list and an expiry duration, applying defaults when they are empty
+func parsePoll(options, expiry string) (*mastodon.TootPoll, error) {
+	if options == "" {
+		options = "👍,👎"
+	}
+	if expiry == "" {
+		expiry = "24h"
+	}
+
+	var pollOptions []string
+	for _, option := range strings.Split(options, ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			pollOptions = append(pollOptions, option)
+		}
+	}
+
+	duration, err := time.ParseDuration(expiry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid POCKET2FEDI_POLL_EXPIRY %q: %w", expiry, err)
+	}
+
+	return &mastodon.TootPoll{
+		Options:          pollOptions,
+		ExpiresInSeconds: int64(duration.Seconds()),
+	}, nil
+}
+
+// validatePoll checks a poll against Mastodon's default poll constraints
+func validatePoll(poll *mastodon.TootPoll) []error {
+	var problems []error
+
+	if len(poll.Options) < 2 || len(poll.Options) > maxPollOptions {
+		problems = append(problems, fmt.Errorf("POCKET2FEDI_POLL_OPTIONS must list between 2 and %d options, got %d", maxPollOptions, len(poll.Options)))
+	}
+	for _, option := range poll.Options {
+		if len([]rune(option)) > maxPollOptionLength {
+			problems = append(problems, fmt.Errorf("poll option %q is longer than %d characters", option, maxPollOptionLength))
+		}
+	}
+
+	duration := time.Duration(poll.ExpiresInSeconds) * time.Second
+	if duration < minPollExpiry || duration > maxPollExpiry {
+		problems = append(problems, fmt.Errorf("POCKET2FEDI_POLL_EXPIRY must be between %v and %v, got %v", minPollExpiry, maxPollExpiry, duration))
+	}
+
+	return problems
+}
@@ -0,0 +1,544 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-mastodon"
+	"github.com/motemen/go-pocket/api"
+)
+
+func TestConfigValidate_ReportsAllProblems(t *testing.T) {
+	config := &Config{
+		Source:             sourcePocket,
+		MastodonServer:     "https://mastodon.example",
+		Output:             outputMastodon,
+		Visibility:         "everyone",
+		WaybackMode:        "sometimes",
+		ImageItemPolicy:    "attach",
+		URLSource:          "canonical",
+		FailureSummary:     "public",
+		EnrichConcurrency:  0,
+		EnrichJitter:       -time.Second,
+		DeampConfirm:       true,
+		Poll:               &mastodon.TootPoll{Options: []string{"Only one"}, ExpiresInSeconds: 60},
+		LongURLPolicy:      longURLsShorten,
+		LongURLPercent:     150,
+		StateBackend:       "redis",
+		PostOrder:          "random",
+		HTTPTimeoutSeconds: -1,
+		AttachImage:        true,
+		PollInterval:       -time.Minute,
+		LogLevel:           "loud",
+		TagAfterPost:       "posted,shared",
+		ContentType:        "podcast",
+		TitleSource:        "best",
+	}
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatalf("Validate should have failed")
+	}
+
+	for _, want := range []string{
+		"POCKET_CONSUMER_KEY",
+		"MASTODON_TOKEN",
+		"MASTODON_VISIBILITY",
+		"POCKET2FEDI_WAYBACK",
+		"POCKET2FEDI_IMAGE_ITEMS",
+		"POCKET2FEDI_URL_SOURCE",
+		"POCKET2FEDI_FAILURE_SUMMARY",
+		"POCKET2FEDI_ENRICH_CONCURRENCY",
+		"POCKET2FEDI_ENRICH_JITTER",
+		"DEAMP_CONFIRM requires DEAMP",
+		"POCKET2FEDI_POLL_OPTIONS",
+		"POCKET2FEDI_POLL_EXPIRY",
+		"requires POCKET2FEDI_SHORTENER",
+		"POCKET2FEDI_LONG_URL_PERCENT",
+		"STATE_BACKEND",
+		"POCKET2FEDI_POST_ORDER",
+		"HTTP_TIMEOUT_SECONDS",
+		"POCKET2FEDI_ATTACH_IMAGE",
+		"POLL_INTERVAL",
+		"LOG_LEVEL",
+		"POCKET_TAG_AFTER_POST",
+		"POCKET_CONTENT_TYPE",
+		"POCKET2FEDI_TITLE_SOURCE",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention '%s', got:\n%v", want, err)
+		}
+	}
+}
+
+func TestConfigValidate_Valid(t *testing.T) {
+	config := &Config{
+		Source:            sourcePocket,
+		PocketConsumerKey: "test_consumer_key",
+		PocketAccessToken: "test_access_token",
+		MastodonServer:    "https://mastodon.example",
+		Output:            outputMastodon,
+		MastodonToken:     "test_mastodon_token",
+		Visibility:        mastodon.VisibilityPublic,
+		ImageItemPolicy:   imageItemsPost,
+		Count:             10,
+		EnrichConcurrency: 4,
+		URLSource:         urlSourceResolved,
+		Concurrency:       1,
+	}
+
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate failed on a valid config: %v", err)
+	}
+}
+
+func TestConfigValidate_ContentTypeNeedsPocket(t *testing.T) {
+	config := &Config{Source: sourceWallabag, ContentType: contentTypeArticle}
+
+	err := config.Validate()
+	if err == nil || !strings.Contains(err.Error(), "POCKET_CONTENT_TYPE is only supported") {
+		t.Errorf("Expected POCKET_CONTENT_TYPE to be rejected for wallabag, got:\n%v", err)
+	}
+}
+
+func TestConfigValidate_BlueskyUnsupportedOptions(t *testing.T) {
+	config := &Config{FediverseType: fediverseBluesky, SpoilerText: "Link", AttachImage: true}
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatalf("Validate should have failed")
+	}
+	for _, want := range []string{"MASTODON_SPOILER_TEXT is not supported", "POCKET2FEDI_ATTACH_IMAGE is not supported"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention '%s', got:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadConfigFromEnv_ReportsParseAndValidationProblemsTogether(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+	os.Setenv("URL_REGEX", "([a-z")
+	os.Setenv("POCKET2FEDI_ENRICH_HOST_DELAY", "soon")
+	os.Setenv("DEAMP", "maybe")
+	defer func() {
+		os.Unsetenv("POCKET_CONSUMER_KEY")
+		os.Unsetenv("MASTODON_SERVER")
+		os.Unsetenv("MASTODON_TOKEN")
+		os.Unsetenv("URL_REGEX")
+		os.Unsetenv("POCKET2FEDI_ENRICH_HOST_DELAY")
+		os.Unsetenv("DEAMP")
+	}()
+
+	_, err := LoadConfigFromEnv()
+	if err == nil {
+		t.Fatalf("LoadConfigFromEnv should have failed")
+	}
+
+	for _, want := range []string{"URL_REGEX", "POCKET2FEDI_ENRICH_HOST_DELAY", "DEAMP", "POCKET_ACCESS_TOKEN"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention '%s', got:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadConfigFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pocket2fedi.yaml")
+	err := os.WriteFile(path, []byte(`pocket_consumer_key: file_consumer_key
+pocket_access_token: file_access_token
+mastodon_server: https://mastodon.example
+mastodon_token: file_mastodon_token
+deamp: true
+`), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	// Environment variables take precedence over the file
+	os.Setenv("MASTODON_TOKEN", "env_mastodon_token")
+	defer os.Unsetenv("MASTODON_TOKEN")
+
+	config, err := LoadConfigFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFromFile failed: %v", err)
+	}
+
+	if config.PocketConsumerKey != "file_consumer_key" || config.PocketAccessToken != "file_access_token" {
+		t.Errorf("Expected Pocket credentials from the file, got '%s' and '%s'", config.PocketConsumerKey, config.PocketAccessToken)
+	}
+	if config.MastodonServer != "https://mastodon.example" {
+		t.Errorf("Expected MastodonServer 'https://mastodon.example', got '%s'", config.MastodonServer)
+	}
+	if config.MastodonToken != "env_mastodon_token" {
+		t.Errorf("Expected MastodonToken from the environment, got '%s'", config.MastodonToken)
+	}
+	if !config.Deamp {
+		t.Errorf("Expected DEAMP from the file to be enabled")
+	}
+	if config.Sources["MastodonServer"] != "file "+path || config.Sources["MastodonToken"] != "env MASTODON_TOKEN" {
+		t.Errorf("Unexpected sources: %v", config.Sources)
+	}
+}
+
+func TestLoadConfigFromFile_TOMLMatchesYAML(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"pocket2fedi.yaml": `pocket_consumer_key: file_consumer_key
+pocket_access_token: file_access_token
+mastodon_server: https://mastodon.example
+mastodon_token: file_mastodon_token
+pocket_fetch_count: 25
+deamp: true
+quarantine: 1h
+mastodon_visibility: unlisted
+targets:
+  - server: https://second.example
+    token: second_token
+  - type: misskey
+    server: https://misskey.example
+    token: misskey_token
+`,
+		"pocket2fedi.toml": `pocket_consumer_key = "file_consumer_key"
+pocket_access_token = "file_access_token"
+mastodon_server = "https://mastodon.example"
+mastodon_token = "file_mastodon_token"
+pocket_fetch_count = 25
+deamp = true
+quarantine = "1h"
+mastodon_visibility = "unlisted"
+
+[[targets]]
+server = "https://second.example"
+token = "second_token"
+
+[[targets]]
+type = "misskey"
+server = "https://misskey.example"
+token = "misskey_token"
+`,
+	}
+
+	// Environment variables take precedence over either kind of file
+	os.Setenv("MASTODON_TOKEN", "env_mastodon_token")
+	defer os.Unsetenv("MASTODON_TOKEN")
+
+	configs := map[string]*Config{}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		config, err := LoadConfigFromFile(path)
+		if err != nil {
+			t.Fatalf("LoadConfigFromFile(%s) failed: %v", name, err)
+		}
+		if config.Sources["MastodonServer"] != "file "+path || config.Sources["MastodonToken"] != "env MASTODON_TOKEN" {
+			t.Errorf("%s: unexpected sources: %v", name, config.Sources)
+		}
+		// Sources name the file, which is all that should differ
+		config.Sources = nil
+		configs[name] = config
+	}
+
+	yamlConfig, tomlConfig := configs["pocket2fedi.yaml"], configs["pocket2fedi.toml"]
+	if !reflect.DeepEqual(yamlConfig, tomlConfig) {
+		t.Errorf("Expected the same config from YAML and TOML, got\n%+v\nand\n%+v", yamlConfig, tomlConfig)
+	}
+	if tomlConfig.MastodonToken != "env_mastodon_token" || tomlConfig.Count != 25 || !tomlConfig.Deamp || tomlConfig.Quarantine != time.Hour || len(tomlConfig.Targets) != 2 {
+		t.Errorf("Expected the settings from the TOML file, got %+v", tomlConfig)
+	}
+}
+
+func TestLoadConfigFromFile_InvalidTOML(t *testing.T) {
+	for name, content := range map[string]string{
+		"syntax":  "mastodon_server = https://mastodon.example\n",
+		"a table": "[mastodon]\nserver = \"https://mastodon.example\"\n",
+	} {
+		path := filepath.Join(t.TempDir(), "pocket2fedi.toml")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		if _, err := LoadConfigFromFile(path); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("%s: expected an error naming the file, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfigFromFile_MissingKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pocket2fedi.yaml")
+	err := os.WriteFile(path, []byte("pocket_consumer_key: file_consumer_key\nmastodon_server: https://mastodon.example\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	_, err = LoadConfigFromFile(path)
+	if err == nil {
+		t.Fatalf("LoadConfigFromFile should have failed on a partial file")
+	}
+	for _, want := range []string{"POCKET_ACCESS_TOKEN", "MASTODON_TOKEN"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention '%s', got:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadConfigFromFile_MissingFile(t *testing.T) {
+	_, err := LoadConfigFromFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "failed to read config file") {
+		t.Errorf("Expected a read error for a missing file, got %v", err)
+	}
+}
+
+func TestLoadConfigFromEnv_FetchCount(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+	defer func() {
+		os.Unsetenv("POCKET_CONSUMER_KEY")
+		os.Unsetenv("POCKET_ACCESS_TOKEN")
+		os.Unsetenv("MASTODON_SERVER")
+		os.Unsetenv("MASTODON_TOKEN")
+		os.Unsetenv("POCKET_FETCH_COUNT")
+	}()
+
+	var requested int
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Count int `json:"count"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requested = body.Count
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": 1, "list": {}}`))
+	}))
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	tests := []struct {
+		value    string
+		expected int
+		wantErr  bool
+	}{
+		{"", 10, false},
+		{"25", 25, false},
+		{"0", 0, true},
+		{"-5", 0, true},
+		{"garbage", 10, false},
+	}
+
+	for _, tt := range tests {
+		if tt.value == "" {
+			os.Unsetenv("POCKET_FETCH_COUNT")
+		} else {
+			os.Setenv("POCKET_FETCH_COUNT", tt.value)
+		}
+
+		config, err := LoadConfigFromEnv()
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "POCKET_FETCH_COUNT") {
+				t.Errorf("POCKET_FETCH_COUNT=%q: expected an error mentioning POCKET_FETCH_COUNT, got %v", tt.value, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("POCKET_FETCH_COUNT=%q: LoadConfigFromEnv failed: %v", tt.value, err)
+		}
+
+		fetcher, err := NewPocketSource(config)
+		if err != nil {
+			t.Fatalf("NewPocketSource failed: %v", err)
+		}
+		if _, err := fetcher.Fetch(context.Background(), time.Time{}); err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+		if requested != tt.expected {
+			t.Errorf("POCKET_FETCH_COUNT=%q: expected count %d to be requested, got %d", tt.value, tt.expected, requested)
+		}
+	}
+}
+
+func TestLoadConfigFromEnv_Visibility(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+	defer func() {
+		os.Unsetenv("POCKET_CONSUMER_KEY")
+		os.Unsetenv("POCKET_ACCESS_TOKEN")
+		os.Unsetenv("MASTODON_SERVER")
+		os.Unsetenv("MASTODON_TOKEN")
+		os.Unsetenv("MASTODON_VISIBILITY")
+	}()
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"", mastodon.VisibilityPublic},
+		{"public", mastodon.VisibilityPublic},
+		{"unlisted", mastodon.VisibilityUnlisted},
+		{"private", mastodon.VisibilityFollowersOnly},
+		{"direct", mastodon.VisibilityDirectMessage},
+	}
+
+	for _, tt := range tests {
+		os.Setenv("MASTODON_VISIBILITY", tt.value)
+		config, err := LoadConfigFromEnv()
+		if err != nil {
+			t.Fatalf("MASTODON_VISIBILITY=%q: LoadConfigFromEnv failed: %v", tt.value, err)
+		}
+		if config.Visibility != tt.expected {
+			t.Errorf("MASTODON_VISIBILITY=%q: expected '%s', got '%s'", tt.value, tt.expected, config.Visibility)
+		}
+	}
+
+	os.Setenv("MASTODON_VISIBILITY", "followers")
+	_, err := LoadConfigFromEnv()
+	if err == nil || !strings.Contains(err.Error(), "valid: public, unlisted, private, direct") {
+		t.Errorf("Expected an error listing the valid visibilities, got %v", err)
+	}
+}
+
+func TestLoadConfigFromEnv_Bluesky(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("FEDIVERSE_TYPE", "bluesky")
+	defer func() {
+		os.Unsetenv("POCKET_CONSUMER_KEY")
+		os.Unsetenv("POCKET_ACCESS_TOKEN")
+		os.Unsetenv("FEDIVERSE_TYPE")
+		os.Unsetenv("BLUESKY_HANDLE")
+		os.Unsetenv("BLUESKY_APP_PASSWORD")
+	}()
+
+	// Bluesky needs its own credentials rather than the Mastodon ones
+	if _, err := LoadConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "BLUESKY_HANDLE") {
+		t.Errorf("Expected an error mentioning BLUESKY_HANDLE, got %v", err)
+	}
+
+	os.Setenv("BLUESKY_HANDLE", "test.bsky.social")
+	os.Setenv("BLUESKY_APP_PASSWORD", "test-app-password")
+	config, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("LoadConfigFromEnv failed: %v", err)
+	}
+	if config.BlueskyPDS != "https://bsky.social" {
+		t.Errorf("Expected the default PDS 'https://bsky.social', got '%s'", config.BlueskyPDS)
+	}
+}
+
+func TestLoadConfigFromEnv_MastodonServer(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+	defer func() {
+		os.Unsetenv("POCKET_CONSUMER_KEY")
+		os.Unsetenv("POCKET_ACCESS_TOKEN")
+		os.Unsetenv("MASTODON_TOKEN")
+		os.Unsetenv("MASTODON_SERVER")
+	}()
+
+	tests := []struct {
+		value    string
+		expected string // the normalized server, or part of the error
+		valid    bool
+	}{
+		{"https://mastodon.example/", "https://mastodon.example", true},
+		{"http://localhost:3000", "http://localhost:3000", true},
+		{"mastodon.example", "must start with https:// or http://", false},
+		{"mastodon.example/@me", "must start with https:// or http://", false},
+		{"https:///@me", "must include a host name", false},
+		{"", "missing required environment variables MASTODON_SERVER", false},
+	}
+
+	for _, tt := range tests {
+		os.Setenv("MASTODON_SERVER", tt.value)
+		config, err := LoadConfigFromEnv()
+		if !tt.valid {
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("MASTODON_SERVER=%q: expected an error containing %q, got %v", tt.value, tt.expected, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("MASTODON_SERVER=%q: LoadConfigFromEnv failed: %v", tt.value, err)
+		}
+		if config.MastodonServer != tt.expected {
+			t.Errorf("MASTODON_SERVER=%q: expected '%s', got '%s'", tt.value, tt.expected, config.MastodonServer)
+		}
+	}
+}
+
+func TestLoadConfigFromEnv_SecretFiles(t *testing.T) {
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	defer os.Unsetenv("MASTODON_SERVER")
+
+	dir := t.TempDir()
+	secrets := []struct {
+		key   string
+		field func(*Config) string
+	}{
+		{"POCKET_CONSUMER_KEY", func(c *Config) string { return c.PocketConsumerKey }},
+		{"POCKET_ACCESS_TOKEN", func(c *Config) string { return c.PocketAccessToken }},
+		{"MASTODON_TOKEN", func(c *Config) string { return c.MastodonToken }},
+		{"WALLABAG_CLIENT_SECRET", func(c *Config) string { return c.WallabagClientSecret }},
+		{"WALLABAG_PASSWORD", func(c *Config) string { return c.WallabagPassword }},
+		{"BLUESKY_APP_PASSWORD", func(c *Config) string { return c.BlueskyAppPassword }},
+	}
+	for _, secret := range secrets {
+		path := filepath.Join(dir, strings.ToLower(secret.key))
+		if err := os.WriteFile(path, []byte("file_"+strings.ToLower(secret.key)+"\n"), 0o600); err != nil {
+			t.Fatalf("failed to write secret file: %v", err)
+		}
+		// The file takes precedence over the variable itself
+		os.Setenv(secret.key, "env_value")
+		os.Setenv(secret.key+"_FILE", path)
+		defer os.Unsetenv(secret.key)
+		defer os.Unsetenv(secret.key + "_FILE")
+	}
+
+	config, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("LoadConfigFromEnv failed: %v", err)
+	}
+	for _, secret := range secrets {
+		expected := "file_" + strings.ToLower(secret.key)
+		if got := secret.field(config); got != expected {
+			t.Errorf("%s_FILE: expected '%s', got '%s'", secret.key, expected, got)
+		}
+	}
+	if source := config.Sources["MastodonToken"]; source != "env MASTODON_TOKEN_FILE" {
+		t.Errorf("Expected the secret's source to be its file variable, got '%s'", source)
+	}
+}
+
+func TestLoadConfigFromEnv_MissingSecretFile(t *testing.T) {
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+	os.Setenv("POCKET_CONSUMER_KEY_FILE", filepath.Join(t.TempDir(), "missing"))
+	defer func() {
+		os.Unsetenv("POCKET_ACCESS_TOKEN")
+		os.Unsetenv("MASTODON_SERVER")
+		os.Unsetenv("MASTODON_TOKEN")
+		os.Unsetenv("POCKET_CONSUMER_KEY_FILE")
+	}()
+
+	_, err := LoadConfigFromEnv()
+	if err == nil || !strings.Contains(err.Error(), "failed to read POCKET_CONSUMER_KEY_FILE") {
+		t.Errorf("Expected an error reading POCKET_CONSUMER_KEY_FILE, got %v", err)
+	}
+}
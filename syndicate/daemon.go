@@ -0,0 +1,43 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultPollInterval is how long a daemon waits between runs when
+// POLL_INTERVAL isn't set
+const defaultPollInterval = 15 * time.Minute
+
+// RunDaemon runs over and over, waiting config.PollInterval (or
+// defaultPollInterval if it isn't set) after each run, until ctx is cancelled. Each run gets a new Poster from newPoster, so
+// thread mode starts a new thread every time, and all of them share store so
+// only new saves are posted. Without a store, what was posted is remembered
+// in memory for as long as the daemon runs.
+func RunDaemon(ctx context.Context, config *Config, source PocketSource, newPoster func(*Config) (Poster, error), store StateStore) error {
+	if store == nil {
+		logger.Info("No state store configured; posted saves are only remembered until the daemon stops")
+		store = newMemoryStateStore()
+	}
+
+	interval := config.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	for {
+		poster, err := newPoster(config)
+		if err != nil {
+			return err
+		}
+		run(ctx, config, source, poster, nil, store)
+
+		logger.Info(fmt.Sprintf("Next run in %v", interval))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
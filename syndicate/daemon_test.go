@@ -0,0 +1,91 @@
+package syndicate
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingSource counts fetches in a way that is safe to read while a
+// daemon is running
+type countingSource struct {
+	FakeSource
+	fetches atomic.Int32
+}
+
+func (s *countingSource) Fetch(ctx context.Context, since time.Time) ([]*PocketItem, error) {
+	s.fetches.Add(1)
+	return s.FakeSource.Fetch(ctx, since)
+}
+
+func TestRunDaemon(t *testing.T) {
+	source := &countingSource{FakeSource: FakeSource{Items: []*PocketItem{
+		{ItemID: "1", Title: "Test Article", URL: "https://example.com/article", IsArticle: true},
+	}}}
+	poster := &FakePoster{}
+	posters := 0
+	newPoster := func(*Config) (Poster, error) {
+		posters++
+		return poster, nil
+	}
+	config := &Config{Output: outputMastodon, FediverseType: fediverseMisskey, PollInterval: 10 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- RunDaemon(ctx, config, source, newPoster, nil) }()
+
+	deadline := time.After(5 * time.Second)
+	for source.fetches.Load() < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("Expected at least 3 polling cycles, got %d", source.fetches.Load())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("RunDaemon failed: %v", err)
+	}
+
+	// The save is only posted by the first cycle, and each cycle gets its
+	// own poster
+	if statuses := poster.Statuses(); len(statuses) != 1 {
+		t.Errorf("Expected 1 status across every cycle, got %q", statuses)
+	}
+	if posters < 3 {
+		t.Errorf("Expected a poster for each of at least 3 cycles, got %d", posters)
+	}
+}
+
+func TestLoadConfigFromEnv_PollInterval(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+	defer func() {
+		os.Unsetenv("POCKET_CONSUMER_KEY")
+		os.Unsetenv("POCKET_ACCESS_TOKEN")
+		os.Unsetenv("MASTODON_SERVER")
+		os.Unsetenv("MASTODON_TOKEN")
+		os.Unsetenv("POLL_INTERVAL")
+	}()
+
+	config, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("LoadConfigFromEnv failed: %v", err)
+	}
+	if config.PollInterval != defaultPollInterval || config.Sources["PollInterval"] != "default" {
+		t.Errorf("Expected the default PollInterval of 15m, got %v from %q", config.PollInterval, config.Sources["PollInterval"])
+	}
+
+	// An explicit zero is rejected rather than replaced with the default
+	for _, value := range []string{"0", "-1m"} {
+		os.Setenv("POLL_INTERVAL", value)
+		if _, err := LoadConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "POLL_INTERVAL") {
+			t.Errorf("POLL_INTERVAL=%s: expected an error mentioning POLL_INTERVAL, got %v", value, err)
+		}
+	}
+}
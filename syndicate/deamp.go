@@ -0,0 +1,60 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// deampURL rewrites AMP and mobile URLs to their usual desktop form by
+// dropping the amp./m. subdomain, a trailing /amp path segment, and the
+// outputType=amp query parameter. URLs that don't parse are returned as-is.
+func deampURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	for _, prefix := range []string{"amp.", "m."} {
+		if rest, ok := strings.CutPrefix(u.Host, prefix); ok && strings.Contains(rest, ".") {
+			u.Host = rest
+			break
+		}
+	}
+
+	if strings.HasSuffix(u.Path, "/amp") || strings.HasSuffix(u.Path, "/amp/") {
+		u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/amp")
+		if u.Path == "" {
+			u.Path = "/"
+		}
+		u.RawPath = ""
+	}
+
+	if query := u.Query(); strings.EqualFold(query.Get("outputType"), "amp") {
+		query.Del("outputType")
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
+
+// deampSaves rewrites the URL of each save to its non-AMP form. When confirm
+// is set, the page's canonical link is preferred over the pattern rewrite.
+func deampSaves(ctx context.Context, pages *pageHeadCache, saves []*PocketItem, confirm bool) {
+	for _, save := range saves {
+		rewritten := deampURL(save.URL)
+		if confirm {
+			head, err := pages.get(ctx, save.URL)
+			if err != nil {
+				logger.Error(fmt.Sprintf("Error fetching canonical link for '%s', using rewritten URL: %v", save.URL, err), itemAttrs(save, "error", err)...)
+			} else if head.Canonical != "" {
+				rewritten = head.Canonical
+			}
+		}
+		if rewritten != save.URL {
+			logger.Debug(fmt.Sprintf("Rewrote '%s' to '%s'", save.URL, rewritten), itemAttrs(save, "rewritten_url", rewritten)...)
+			save.URL = rewritten
+		}
+	}
+}
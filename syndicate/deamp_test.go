@@ -0,0 +1,63 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeampURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"https://amp.theguardian.com/world/2024/story", "https://theguardian.com/world/2024/story"},
+		{"https://m.example.com/article?id=7", "https://example.com/article?id=7"},
+		{"https://www.example.com/news/story/amp", "https://www.example.com/news/story"},
+		{"https://www.example.com/news/story/amp/", "https://www.example.com/news/story"},
+		{"https://www.example.com/story?outputType=amp", "https://www.example.com/story"},
+		{"https://www.example.com/story?page=2&outputType=amp", "https://www.example.com/story?page=2"},
+		// Not AMP or mobile URLs, left untouched
+		{"https://www.example.com/story", "https://www.example.com/story"},
+		{"https://example.com/amplifier", "https://example.com/amplifier"},
+		{"https://m.com/article", "https://m.com/article"},
+		{"not a url", "not a url"},
+	}
+
+	for _, tt := range tests {
+		if got := deampURL(tt.input); got != tt.expected {
+			t.Errorf("deampURL(%q): expected '%s', got '%s'", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestDeampSaves_ConfirmWithCanonicalLink(t *testing.T) {
+	mockPage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><link rel="canonical" href="/2024/real-story.html"></head><body></body></html>`))
+	}))
+	defer mockPage.Close()
+
+	saves := []*PocketItem{{Title: "Story", URL: mockPage.URL + "/2024/real-story/amp"}}
+	deampSaves(context.Background(), newPageHeadCache(nil), saves, true)
+
+	expected := mockPage.URL + "/2024/real-story.html"
+	if saves[0].URL != expected {
+		t.Errorf("Expected canonical URL '%s', got '%s'", expected, saves[0].URL)
+	}
+}
+
+func TestDeampSaves_ConfirmFallsBackToRewrite(t *testing.T) {
+	mockPage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer mockPage.Close()
+
+	saves := []*PocketItem{{Title: "Story", URL: mockPage.URL + "/story/amp"}}
+	deampSaves(context.Background(), newPageHeadCache(nil), saves, true)
+
+	expected := mockPage.URL + "/story"
+	if saves[0].URL != expected {
+		t.Errorf("Expected rewritten URL '%s', got '%s'", expected, saves[0].URL)
+	}
+}
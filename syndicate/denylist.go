@@ -0,0 +1,91 @@
+package syndicate
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// loadDeniedItems reads a file of item IDs to never post, one per line.
+// Blank lines and lines starting with # are ignored.
+func loadDeniedItems(path string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open denied items file: %w", err)
+	}
+	defer file.Close()
+
+	denied := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		denied[line] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read denied items file: %w", err)
+	}
+
+	return denied, nil
+}
+
+// skipDeniedItems drops saves whose item ID is listed in the denied items
+// file. The file is re-read on every call so edits apply to the next run.
+func skipDeniedItems(saves []*PocketItem, path string) ([]*PocketItem, error) {
+	if path == "" {
+		return saves, nil
+	}
+
+	denied, err := loadDeniedItems(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []*PocketItem
+	for _, save := range saves {
+		if denied[save.ItemID] {
+			logger.Debug(fmt.Sprintf("Skipping denied item %s '%s'", save.ItemID, save.URL), itemAttrs(save)...)
+			continue
+		}
+		kept = append(kept, save)
+	}
+	return kept, nil
+}
+
+// parseDomainList splits a comma-separated list of domains, lowercasing each
+// and dropping blanks and any leading "*." or "."
+func parseDomainList(value string) []string {
+	var domains []string
+	for _, domain := range strings.Split(value, ",") {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		domain = strings.TrimPrefix(strings.TrimPrefix(domain, "*"), ".")
+		if domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// isBlocked reports whether rawURL's host is one of the blocklist domains or
+// a subdomain of one, so blocking example.com also blocks www.example.com
+func isBlocked(rawURL string, blocklist []string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "" {
+		return false
+	}
+	for _, domain := range blocklist {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package syndicate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSkipDeniedItems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denied-items")
+	err := os.WriteFile(path, []byte("# mis-saves I never want shared\n456\n\n  789  \n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write denied items file: %v", err)
+	}
+
+	saves := []*PocketItem{
+		{ItemID: "123", Title: "Test Article 1", URL: "https://example.com/article1"},
+		{ItemID: "456", Title: "Test Article 2", URL: "https://example.com/changing?session=a"},
+		{ItemID: "789", Title: "Test Article 3", URL: "https://example.com/article3"},
+	}
+
+	kept, err := skipDeniedItems(saves, path)
+	if err != nil {
+		t.Fatalf("skipDeniedItems failed: %v", err)
+	}
+	if len(kept) != 1 || kept[0].ItemID != "123" {
+		t.Errorf("Expected only item 123 to remain, got %+v", kept)
+	}
+
+	// The file is re-read each time, so removing an ID takes effect
+	if err := os.WriteFile(path, []byte("789\n"), 0o644); err != nil {
+		t.Fatalf("Failed to rewrite denied items file: %v", err)
+	}
+	kept, err = skipDeniedItems(saves, path)
+	if err != nil {
+		t.Fatalf("skipDeniedItems failed: %v", err)
+	}
+	if len(kept) != 2 {
+		t.Errorf("Expected 2 items after editing the file, got %d", len(kept))
+	}
+}
+
+func TestSkipDeniedItems_NoFileConfigured(t *testing.T) {
+	saves := []*PocketItem{{ItemID: "123", URL: "https://example.com/article1"}}
+
+	kept, err := skipDeniedItems(saves, "")
+	if err != nil {
+		t.Fatalf("skipDeniedItems failed: %v", err)
+	}
+	if len(kept) != 1 {
+		t.Errorf("Expected all items to be kept, got %d", len(kept))
+	}
+}
+
+func TestSkipDeniedItems_MissingFile(t *testing.T) {
+	_, err := skipDeniedItems(nil, filepath.Join(t.TempDir(), "missing"))
+	if err == nil {
+		t.Errorf("skipDeniedItems should have failed for a missing file")
+	}
+}
+
+func TestIsBlocked(t *testing.T) {
+	blocklist := parseDomainList(" Example.com, *.paywalled.news, .ft.com,, ")
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"https://example.com/article", true},
+		{"https://www.example.com/article", true},
+		{"https://a.b.example.com/article", true},
+		{"https://EXAMPLE.COM./article", true},
+		{"https://example.com:8443/article", true},
+		{"https://www.paywalled.news/story", true},
+		{"https://paywalled.news/story", true},
+		{"https://ft.com/content/1", true},
+		{"https://notexample.com/article", false},
+		{"https://example.com.evil.org/article", false},
+		{"https://example.org/article", false},
+		{"not a url", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isBlocked(tt.url, blocklist); got != tt.expected {
+			t.Errorf("isBlocked(%q): expected %v, got %v", tt.url, tt.expected, got)
+		}
+	}
+
+	if isBlocked("https://example.com/article", nil) {
+		t.Errorf("Expected nothing to be blocked with an empty blocklist")
+	}
+}
+
+func TestFilterSaves_DomainBlocklist(t *testing.T) {
+	saves := []*PocketItem{
+		{Title: "Test Article 1", URL: "https://www.example.com/article1", IsArticle: true},
+		{Title: "Test Article 2", URL: "https://example.org/article2", IsArticle: true},
+	}
+
+	filtered := filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost, DomainBlocklist: []string{"example.com"}})
+	if len(filtered) != 1 || filtered[0].Title != "Test Article 2" {
+		t.Errorf("Expected only the save from an unblocked domain, got %+v", filtered)
+	}
+}
@@ -0,0 +1,29 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DumpItems fetches saves from source and writes them to w as an indented
+// JSON array, with every parsed field, for crafting filters and templates.
+// Nothing is filtered or posted.
+func DumpItems(ctx context.Context, source PocketSource, w io.Writer) error {
+	saves, err := source.Fetch(ctx, time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to fetch saves: %w", err)
+	}
+	if saves == nil {
+		saves = []*PocketItem{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(saves); err != nil {
+		return fmt.Errorf("failed to write saves as JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package syndicate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDumpItems(t *testing.T) {
+	added := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	source := &FakeSource{Items: []*PocketItem{{
+		ItemID:      "123",
+		Title:       "Test Article",
+		URL:         "https://example.com/article",
+		GivenURL:    "https://example.com/article?utm_source=feed",
+		ResolvedURL: "https://example.com/article",
+		IsArticle:   true,
+		Excerpt:     "An excerpt",
+		Tags:        []string{"go", "testing"},
+		TimeAdded:   added,
+		ImageURL:    "https://example.com/lead.jpg",
+	}}}
+
+	var out bytes.Buffer
+	if err := DumpItems(context.Background(), source, &out); err != nil {
+		t.Fatalf("DumpItems failed: %v", err)
+	}
+
+	var items []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &items); err != nil {
+		t.Fatalf("Expected valid JSON, got %v:\n%s", err, out.String())
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(items))
+	}
+
+	// Every field of PocketItem is included
+	fields := reflect.TypeOf(PocketItem{})
+	for i := 0; i < fields.NumField(); i++ {
+		if _, ok := items[0][fields.Field(i).Name]; !ok {
+			t.Errorf("Expected field %s in the output", fields.Field(i).Name)
+		}
+	}
+	expected := map[string]any{
+		"ItemID":    "123",
+		"Title":     "Test Article",
+		"GivenURL":  "https://example.com/article?utm_source=feed",
+		"IsArticle": true,
+		"Tags":      []any{"go", "testing"},
+		"TimeAdded": "2024-01-01T12:00:00Z",
+		"ImageURL":  "https://example.com/lead.jpg",
+	}
+	for field, want := range expected {
+		if got := items[0][field]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", field, want, got)
+		}
+	}
+}
+
+func TestDumpItems_Empty(t *testing.T) {
+	var out bytes.Buffer
+	if err := DumpItems(context.Background(), &FakeSource{}, &out); err != nil {
+		t.Fatalf("DumpItems failed: %v", err)
+	}
+	if out.String() != "[]\n" {
+		t.Errorf("Expected an empty JSON array, got %q", out.String())
+	}
+
+	if err := DumpItems(context.Background(), &FakeSource{Err: errors.New("Pocket unavailable")}, &out); err == nil {
+		t.Errorf("Expected the fetch error, got none")
+	}
+}
@@ -0,0 +1,56 @@
+package syndicate
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// secretFields are config fields whose values are never printed
+var secretFields = map[string]bool{
+	"PocketConsumerKey":    true,
+	"PocketAccessToken":    true,
+	"WallabagClientSecret": true,
+	"WallabagPassword":     true,
+	"MastodonToken":        true,
+	"BlueskyAppPassword":   true,
+	"Proxy":                true, // may carry proxy credentials
+	"Targets":              true, // carry access tokens
+}
+
+// partSources are the Sources entries for settings that are read into part
+// of another field, such as the poll's options
+var partSources = map[string][]string{
+	"Poll": {"PollOptions", "PollExpiry"},
+}
+
+// ExplainConfig writes each effective config field, its value, and where
+// that value came from. Secret values are redacted but their source is shown.
+func ExplainConfig(w io.Writer, config *Config) {
+	v := reflect.ValueOf(config).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Name == "Sources" {
+			continue
+		}
+
+		value := fmt.Sprint(v.Field(i).Interface())
+		if secretFields[field.Name] && !v.Field(i).IsZero() {
+			value = "<redacted>"
+		}
+
+		source := config.Sources[field.Name]
+		if source == "" {
+			source = "unset"
+		}
+		for _, part := range partSources[field.Name] {
+			if partSource := config.Sources[part]; partSource != "" {
+				source += ", " + partSource
+			}
+		}
+
+		fmt.Fprintf(w, "%-20s = %-30q (%s)\n", field.Name, value, source)
+	}
+}
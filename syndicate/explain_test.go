@@ -0,0 +1,90 @@
+package syndicate
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExplainConfig_Provenance(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+	defer func() {
+		os.Unsetenv("POCKET_CONSUMER_KEY")
+		os.Unsetenv("POCKET_ACCESS_TOKEN")
+		os.Unsetenv("MASTODON_SERVER")
+		os.Unsetenv("MASTODON_TOKEN")
+	}()
+
+	config, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("LoadConfigFromEnv failed: %v", err)
+	}
+
+	if config.Sources["MastodonServer"] != "env MASTODON_SERVER" {
+		t.Errorf("Expected MastodonServer from 'env MASTODON_SERVER', got '%s'", config.Sources["MastodonServer"])
+	}
+	if config.Sources["ImageItemPolicy"] != "default" {
+		t.Errorf("Expected ImageItemPolicy from 'default', got '%s'", config.Sources["ImageItemPolicy"])
+	}
+	if _, ok := config.Sources["WaybackMode"]; ok {
+		t.Errorf("Expected WaybackMode to have no source, got '%s'", config.Sources["WaybackMode"])
+	}
+
+	var out bytes.Buffer
+	ExplainConfig(&out, config)
+	lines := out.String()
+
+	if strings.Contains(lines, "test_mastodon_token") || strings.Contains(lines, "test_access_token") {
+		t.Errorf("Secret values should be redacted, got:\n%s", lines)
+	}
+
+	for _, want := range []string{
+		`"https://mastodon.example"`,
+		"(env MASTODON_SERVER)",
+		"(env MASTODON_TOKEN)",
+		"(default)",
+		"(unset)",
+	} {
+		if !strings.Contains(lines, want) {
+			t.Errorf("Expected output to contain '%s', got:\n%s", want, lines)
+		}
+	}
+}
+
+func TestExplainConfig_PollSources(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+	os.Setenv("POCKET2FEDI_POLL", "true")
+	os.Setenv("POCKET2FEDI_POLL_OPTIONS", "Yes,No")
+	os.Setenv("POCKET2FEDI_POLL_EXPIRY", "1h")
+	defer func() {
+		os.Unsetenv("POCKET_CONSUMER_KEY")
+		os.Unsetenv("POCKET_ACCESS_TOKEN")
+		os.Unsetenv("MASTODON_SERVER")
+		os.Unsetenv("MASTODON_TOKEN")
+		os.Unsetenv("POCKET2FEDI_POLL")
+		os.Unsetenv("POCKET2FEDI_POLL_OPTIONS")
+		os.Unsetenv("POCKET2FEDI_POLL_EXPIRY")
+	}()
+
+	config, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("LoadConfigFromEnv failed: %v", err)
+	}
+
+	if config.Sources["PollOptions"] != "env POCKET2FEDI_POLL_OPTIONS" || config.Sources["PollExpiry"] != "env POCKET2FEDI_POLL_EXPIRY" {
+		t.Errorf("Expected the poll options and expiry from the environment, got %q and %q", config.Sources["PollOptions"], config.Sources["PollExpiry"])
+	}
+
+	var out bytes.Buffer
+	ExplainConfig(&out, config)
+	if want := "(env POCKET2FEDI_POLL, env POCKET2FEDI_POLL_OPTIONS, env POCKET2FEDI_POLL_EXPIRY)"; !strings.Contains(out.String(), want) {
+		t.Errorf("Expected output to contain '%s', got:\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,55 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakeSource is a PocketSource returning canned saves or an error, for
+// testing code that embeds Run
+type FakeSource struct {
+	Items []*PocketItem
+	Err   error
+	Calls int       // how many times Fetch was called
+	Since time.Time // the since passed to the last Fetch
+}
+
+// Fetch returns Items, or Err if it is set
+func (s *FakeSource) Fetch(ctx context.Context, since time.Time) ([]*PocketItem, error) {
+	s.Calls++
+	s.Since = since
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return s.Items, nil
+}
+
+// FakePoster is a Poster that records statuses in memory instead of posting
+// them, for testing code that embeds Run
+type FakePoster struct {
+	// Err, when set, is returned for every post and nothing is recorded
+	Err error
+
+	mu       sync.Mutex
+	statuses []string
+}
+
+// Post records status and returns a made-up URL for it
+func (p *FakePoster) Post(ctx context.Context, status string) (string, error) {
+	if p.Err != nil {
+		return "", p.Err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statuses = append(p.statuses, status)
+	return fmt.Sprintf("https://fediverse.example/statuses/%d", len(p.statuses)), nil
+}
+
+// Statuses returns the statuses posted so far, in order
+func (p *FakePoster) Statuses() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.statuses...)
+}
@@ -0,0 +1,72 @@
+package syndicate
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRun_FakePoster(t *testing.T) {
+	source := &FakeSource{Items: []*PocketItem{
+		{ItemID: "1", Title: "First Article", URL: "https://example.com/first", IsArticle: true},
+		{ItemID: "2", Title: "Second Article", URL: "https://example.com/second", IsArticle: true},
+	}}
+	poster := &FakePoster{}
+	// Misskey skips the instance limits lookup, which needs a real server
+	config := &Config{Output: outputMastodon, FediverseType: fediverseMisskey, Concurrency: 1}
+
+	result := Run(context.Background(), config, source, poster, nil)
+	if result.Err != nil || len(result.Errs) != 0 {
+		t.Fatalf("Run failed: %v %v", result.Err, result.Errs)
+	}
+	if result.Posted != 2 || source.Calls != 1 {
+		t.Errorf("Expected 2 posted from 1 fetch, got %d posted from %d fetches", result.Posted, source.Calls)
+	}
+
+	expected := []string{
+		"New Pocket save: First Article - https://example.com/first",
+		"New Pocket save: Second Article - https://example.com/second",
+	}
+	statuses := poster.Statuses()
+	if len(statuses) != len(expected) {
+		t.Fatalf("Expected %d statuses, got %q", len(expected), statuses)
+	}
+	for i := range expected {
+		if statuses[i] != expected[i] {
+			t.Errorf("Status %d: expected '%s', got '%s'", i+1, expected[i], statuses[i])
+		}
+	}
+	if code := result.ExitCode(ExitSuccess); code != ExitSuccess {
+		t.Errorf("Expected exit code %d, got %d", ExitSuccess, code)
+	}
+}
+
+func TestRun_FakePosterFailure(t *testing.T) {
+	source := &FakeSource{Items: []*PocketItem{{ItemID: "1", Title: "Test Article", URL: "https://example.com/article", IsArticle: true}}}
+	poster := &FakePoster{Err: errors.New("server unavailable")}
+	config := &Config{Output: outputMastodon, FediverseType: fediverseMisskey, Concurrency: 1}
+
+	result := Run(context.Background(), config, source, poster, nil)
+	if result.Posted != 0 || len(result.Errs) != 1 {
+		t.Errorf("Expected 0 posted and 1 failed, got %d posted and %d failed", result.Posted, len(result.Errs))
+	}
+	if len(poster.Statuses()) != 0 {
+		t.Errorf("Expected nothing recorded, got %q", poster.Statuses())
+	}
+	if code := result.ExitCode(ExitSuccess); code != ExitFailure {
+		t.Errorf("Expected exit code %d, got %d", ExitFailure, code)
+	}
+}
+
+func TestRun_FakeSourceError(t *testing.T) {
+	source := &FakeSource{Err: errors.New("Pocket unavailable")}
+	poster := &FakePoster{}
+
+	result := Run(context.Background(), &Config{Output: outputMastodon, FediverseType: fediverseMisskey}, source, poster, nil)
+	if result.Err == nil {
+		t.Errorf("Expected the fetch error, got none")
+	}
+	if len(poster.Statuses()) != 0 {
+		t.Errorf("Expected nothing posted, got %q", poster.Statuses())
+	}
+}
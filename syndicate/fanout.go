@@ -0,0 +1,55 @@
+package syndicate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// fanOutPoster posts every status to several accounts
+type fanOutPoster struct {
+	names   []string // the server of each account, for errors
+	posters []Poster
+}
+
+// fanOutError lists the accounts a status couldn't be posted to
+type fanOutError struct {
+	posted int // how many accounts took the status
+	errs   []error
+}
+
+func (e *fanOutError) Error() string {
+	return fmt.Sprintf("posted to %d of %d accounts: %v", e.posted, e.posted+len(e.errs), errors.Join(e.errs...))
+}
+
+func (e *fanOutError) Unwrap() []error {
+	return e.errs
+}
+
+// Post posts status to every account without options
+func (p *fanOutPoster) Post(ctx context.Context, status string) (string, error) {
+	return p.PostWithOptions(ctx, status, statusOptions{})
+}
+
+// PostWithOptions posts status to each account in turn, carrying on past
+// failures. It returns the URL from the first account that took the status
+// and, if any account failed, a *fanOutError listing them.
+func (p *fanOutPoster) PostWithOptions(ctx context.Context, status string, opts statusOptions) (string, error) {
+	var statusURL string
+	result := &fanOutError{}
+	for i, poster := range p.posters {
+		url, err := postStatus(ctx, poster, status, opts)
+		if err != nil {
+			result.errs = append(result.errs, fmt.Errorf("%s: %w", p.names[i], err))
+			continue
+		}
+		if result.posted == 0 {
+			statusURL = url
+		}
+		result.posted++
+	}
+	if len(result.errs) > 0 {
+		return statusURL, result
+	}
+	return statusURL, nil
+}
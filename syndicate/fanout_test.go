@@ -0,0 +1,181 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPostSaves_FanOut(t *testing.T) {
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingServer.Close()
+
+	var statuses []string
+	workingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Header.Get("Authorization") != "Bearer project_token" {
+			t.Errorf("Expected the target's token, got '%s'", r.Header.Get("Authorization"))
+		}
+		statuses = append(statuses, r.PostForm.Get("status"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer workingServer.Close()
+
+	store, err := loadFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("loadFileStateStore failed: %v", err)
+	}
+
+	// The primary account fails, but the target still gets the post
+	config := &Config{
+		MastodonServer: failingServer.URL,
+		MastodonToken:  "personal_token",
+		Output:         outputMastodon,
+		Targets:        []Target{{Server: workingServer.URL, Token: "project_token"}},
+	}
+	posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, store, 0, []*PocketItem{
+		{ItemID: "1", Title: "Test Article", URL: "https://example.com/article"},
+	})
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+
+	if len(statuses) != 1 || !strings.Contains(statuses[0], "Test Article") {
+		t.Errorf("Expected the target to receive the post, got %q", statuses)
+	}
+	// The save is recorded so the target doesn't get it twice, but the
+	// failure is still reported against the account that missed it
+	if posted != 1 || len(errs) != 1 {
+		t.Fatalf("Expected 1 posted and 1 failed, got %d posted and %d failed", posted, len(errs))
+	}
+	if !strings.Contains(errs[0].Error(), failingServer.URL) || !strings.Contains(errs[0].Error(), "posted to 1 of 2 accounts") {
+		t.Errorf("Expected the error to name the failed account, got: %v", errs[0])
+	}
+	if !store.Posted("1") {
+		t.Errorf("Expected the save to be recorded as posted")
+	}
+}
+
+func TestPostSaves_FanOutAllFail(t *testing.T) {
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingServer.Close()
+
+	config := &Config{
+		MastodonServer: failingServer.URL,
+		MastodonToken:  "personal_token",
+		Output:         outputMastodon,
+		Targets:        []Target{{Type: fediverseMisskey, Server: failingServer.URL, Token: "project_token"}},
+	}
+	posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, []*PocketItem{
+		{ItemID: "1", Title: "Test Article", URL: "https://example.com/article"},
+	})
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+	if posted != 0 || len(errs) != 1 {
+		t.Errorf("Expected 0 posted and 1 failed, got %d posted and %d failed", posted, len(errs))
+	}
+}
+
+func TestPostSaves_FanOutDuplicateAndFailure(t *testing.T) {
+	duplicateServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"error": "Duplicate status"}`))
+	}))
+	defer duplicateServer.Close()
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingServer.Close()
+
+	store, err := loadFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("loadFileStateStore failed: %v", err)
+	}
+
+	// One account already has the status, but the other really failed, so
+	// the save must be tried again
+	config := &Config{
+		MastodonServer: duplicateServer.URL,
+		MastodonToken:  "personal_token",
+		Output:         outputMastodon,
+		Targets:        []Target{{Server: failingServer.URL, Token: "project_token"}},
+	}
+	posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, store, 0, []*PocketItem{
+		{ItemID: "1", Title: "Test Article", URL: "https://example.com/article"},
+	})
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+	if posted != 0 || len(errs) != 1 {
+		t.Errorf("Expected 0 posted and 1 failed, got %d posted and %d failed", posted, len(errs))
+	}
+	if store.Posted("1") {
+		t.Errorf("Expected the save not to be recorded as posted")
+	}
+}
+
+func TestLoadConfigFromFile_Targets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pocket2fedi.yaml")
+	err := os.WriteFile(path, []byte(`pocket_consumer_key: file_consumer_key
+pocket_access_token: file_access_token
+mastodon_server: https://personal.example
+mastodon_token: personal_token
+targets:
+  - server: https://project.example/
+    token: project_token
+  - type: misskey
+    server: https://misskey.example
+    token: misskey_token
+`), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := LoadConfigFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFromFile failed: %v", err)
+	}
+	expected := []Target{
+		{Server: "https://project.example", Token: "project_token"},
+		{Type: fediverseMisskey, Server: "https://misskey.example", Token: "misskey_token"},
+	}
+	if len(config.Targets) != len(expected) {
+		t.Fatalf("Expected %d targets, got %+v", len(expected), config.Targets)
+	}
+	for i := range expected {
+		if config.Targets[i] != expected[i] {
+			t.Errorf("Target %d: expected %+v, got %+v", i+1, expected[i], config.Targets[i])
+		}
+	}
+	if config.Sources["Targets"] != "file "+path {
+		t.Errorf("Expected targets to come from the file, got '%s'", config.Sources["Targets"])
+	}
+}
+
+func TestConfigValidate_Targets(t *testing.T) {
+	config := &Config{Targets: []Target{
+		{Server: "project.example", Token: "project_token"},
+		{Server: "https://project.example"},
+		{Type: fediverseBluesky, Server: "https://bsky.social", Token: "token"},
+	}}
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatalf("Validate should have failed")
+	}
+	for _, want := range []string{"invalid server \"project.example\" for target 1", "missing token for target 2", "invalid type \"bluesky\" for target 3"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention '%s', got:\n%v", want, err)
+		}
+	}
+}
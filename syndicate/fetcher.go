@@ -0,0 +1,68 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Supported read-later sources
+const (
+	sourcePocket    = "pocket"
+	sourceWallabag  = "wallabag"
+	sourceJSONLines = "json-lines"
+)
+
+// PocketSource retrieves recent unread saves from a read-later service. Items
+// from every source are returned as PocketItems so the filtering, formatting,
+// and posting code is shared. A non-zero since asks only for saves changed after
+// that time, where the source supports it.
+type PocketSource interface {
+	Fetch(ctx context.Context, since time.Time) ([]*PocketItem, error)
+}
+
+// pocketFetcher fetches saves from the Pocket API
+type pocketFetcher struct {
+	consumerKey string
+	accessToken string
+	urlSource   string
+	titleSource string
+	count       int
+	favorites   bool
+	contentType string
+}
+
+// Fetch returns the most recent unread Pocket saves
+func (f *pocketFetcher) Fetch(ctx context.Context, since time.Time) ([]*PocketItem, error) {
+	return getRecentPocketSaves(ctx, f, since)
+}
+
+// NewPocketSource returns the PocketSource for the configured source
+func NewPocketSource(config *Config) (PocketSource, error) {
+	switch config.Source {
+	case sourcePocket:
+		return &pocketFetcher{
+			consumerKey: config.PocketConsumerKey,
+			accessToken: config.PocketAccessToken,
+			urlSource:   config.URLSource,
+			titleSource: config.TitleSource,
+			count:       config.Count,
+			favorites:   config.FavoritesOnly,
+			contentType: config.ContentType,
+		}, nil
+	case sourceWallabag:
+		return &wallabagFetcher{
+			server:       config.WallabagServer,
+			clientID:     config.WallabagClientID,
+			clientSecret: config.WallabagClientSecret,
+			username:     config.WallabagUsername,
+			password:     config.WallabagPassword,
+			urlSource:    config.URLSource,
+			count:        config.Count,
+		}, nil
+	case sourceJSONLines:
+		return &jsonLinesFetcher{path: config.JSONLinesInput}, nil
+	default:
+		return nil, fmt.Errorf("unknown source %q", config.Source)
+	}
+}
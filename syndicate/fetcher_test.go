@@ -0,0 +1,41 @@
+package syndicate
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewPocketSource(t *testing.T) {
+	fetcher, err := NewPocketSource(&Config{Source: sourcePocket, PocketConsumerKey: "key", PocketAccessToken: "token"})
+	if err != nil {
+		t.Fatalf("NewPocketSource failed: %v", err)
+	}
+	if _, ok := fetcher.(*pocketFetcher); !ok {
+		t.Errorf("Expected a pocketFetcher, got %T", fetcher)
+	}
+
+	fetcher, err = NewPocketSource(&Config{Source: sourceWallabag, WallabagServer: "https://wallabag.example"})
+	if err != nil {
+		t.Fatalf("NewPocketSource failed: %v", err)
+	}
+	if _, ok := fetcher.(*wallabagFetcher); !ok {
+		t.Errorf("Expected a wallabagFetcher, got %T", fetcher)
+	}
+
+	if _, err := NewPocketSource(&Config{Source: "instapaper"}); err == nil {
+		t.Errorf("NewPocketSource should have rejected an unknown source")
+	}
+}
+
+func TestCountNewItems_FetchError(t *testing.T) {
+	fetcher := &FakeSource{Err: errors.New("source unavailable")}
+
+	_, err := CountNewItems(context.Background(), &Config{}, fetcher, nil)
+	if err == nil {
+		t.Errorf("CountNewItems should have failed")
+	}
+	if fetcher.Calls != 1 {
+		t.Errorf("Expected 1 fetch, got %d", fetcher.Calls)
+	}
+}
@@ -0,0 +1,50 @@
+package syndicate
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tagsToHashtags turns tags into space-separated Mastodon hashtags. Multi-word
+// tags are camelCased ("machine learning" becomes #machineLearning), characters
+// that can't appear in a hashtag are dropped, and tags that come out empty,
+// all digits, or the same as an earlier one (ignoring case) are skipped, as are
+// cw: tags. Tags that are already hashtags are kept as they are.
+func tagsToHashtags(tags []string) string {
+	var hashtags []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, cwTagPrefix) {
+			continue
+		}
+
+		hashtag := camelCaseTag(tag)
+		if hashtag == "" || strings.IndexFunc(hashtag, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		if key := strings.ToLower(hashtag); !seen[key] {
+			seen[key] = true
+			hashtags = append(hashtags, "#"+hashtag)
+		}
+	}
+	return strings.Join(hashtags, " ")
+}
+
+// camelCaseTag joins the words of tag, capitalizing all but the first
+func camelCaseTag(tag string) string {
+	words := strings.FieldsFunc(tag, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+
+	var hashtag strings.Builder
+	for i, word := range words {
+		if i > 0 {
+			first, size := utf8.DecodeRuneInString(word)
+			hashtag.WriteRune(unicode.ToUpper(first))
+			word = word[size:]
+		}
+		hashtag.WriteString(word)
+	}
+	return hashtag.String()
+}
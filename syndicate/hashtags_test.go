@@ -0,0 +1,67 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTagsToHashtags(t *testing.T) {
+	tests := []struct {
+		tags     []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{}, ""},
+		{[]string{"golang"}, "#golang"},
+		{[]string{"machine learning"}, "#machineLearning"},
+		{[]string{"open-source software"}, "#openSourceSoftware"},
+		{[]string{"c++", "node.js", "what?!"}, "#c #nodeJs #what"},
+		{[]string{"#golang", "#NoFilter"}, "#golang #NoFilter"},
+		{[]string{"go", "Go", "#go"}, "#go"},
+		{[]string{"2024", "web3", "!!!"}, "#web3"},
+		{[]string{"café crème", "snake_case"}, "#caféCrème #snake_case"},
+		{[]string{"cw:politics", "news"}, "#news"},
+	}
+
+	for _, tt := range tests {
+		if got := tagsToHashtags(tt.tags); got != tt.expected {
+			t.Errorf("tagsToHashtags(%q): expected '%s', got '%s'", tt.tags, tt.expected, got)
+		}
+	}
+}
+
+func TestPostSaves_HashtagsFromTags(t *testing.T) {
+	var status string
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		status = r.PostForm.Get("status")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	tests := []struct {
+		enabled  bool
+		tags     []string
+		expected string
+	}{
+		{true, []string{"golang", "machine learning"}, "New Pocket save: Test Article - https://example.com/article #golang #machineLearning"},
+		{true, nil, "New Pocket save: Test Article - https://example.com/article"},
+		{false, []string{"golang"}, "New Pocket save: Test Article - https://example.com/article"},
+	}
+
+	for _, tt := range tests {
+		config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Output: outputMastodon, HashtagsFromTags: tt.enabled}
+		_, _, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, []*PocketItem{
+			{Title: "Test Article", URL: "https://example.com/article", Tags: tt.tags},
+		})
+		if err != nil {
+			t.Fatalf("postSaves failed: %v", err)
+		}
+		if status != tt.expected {
+			t.Errorf("HashtagsFromTags=%v, tags %q: expected '%s', got '%s'", tt.enabled, tt.tags, tt.expected, status)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/motemen/go-pocket/api"
+)
+
+// healthTimeout bounds each dependency check made by -health-once
+var healthTimeout = 5 * time.Second
+
+// dependencyCheck is one dependency probed by -health-once
+type dependencyCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// dependencyChecks lists the read-only checks for everything the configured
+// run depends on
+func dependencyChecks(config *Config) []dependencyCheck {
+	var checks []dependencyCheck
+
+	switch config.Source {
+	case sourcePocket:
+		checks = append(checks, dependencyCheck{"pocket", func(ctx context.Context) error {
+			return checkReachable(ctx, api.Origin)
+		}})
+	case sourceWallabag:
+		checks = append(checks, dependencyCheck{"wallabag", func(ctx context.Context) error {
+			return checkReachable(ctx, config.WallabagServer)
+		}})
+	case sourceJSONLines:
+		if config.JSONLinesInput != "-" {
+			checks = append(checks, dependencyCheck{"json-lines", func(ctx context.Context) error {
+				return checkReadable(config.JSONLinesInput)
+			}})
+		}
+	}
+
+	if config.DeniedItemsFile != "" {
+		checks = append(checks, dependencyCheck{"denied-items", func(ctx context.Context) error {
+			return checkReadable(config.DeniedItemsFile)
+		}})
+	}
+
+	statePath := config.StateFile
+	if config.StateBackend == stateBackendSQLite {
+		statePath = config.StateDBPath
+	}
+	if statePath != "" {
+		checks = append(checks, dependencyCheck{"state", func(ctx context.Context) error {
+			return checkWritable(statePath)
+		}})
+	}
+
+	if config.Output == outputMastodon {
+		switch config.FediverseType {
+		case fediverseMisskey:
+			checks = append(checks, dependencyCheck{"misskey", func(ctx context.Context) error {
+				return checkReachable(ctx, config.MastodonServer)
+			}})
+		case fediverseBluesky:
+			checks = append(checks, dependencyCheck{"bluesky", func(ctx context.Context) error {
+				return checkReachable(ctx, config.BlueskyPDS)
+			}})
+		default:
+			checks = append(checks, dependencyCheck{"mastodon", func(ctx context.Context) error {
+				return checkMastodonHealth(ctx, config.MastodonServer)
+			}})
+		}
+
+		for i, target := range config.Targets {
+			checks = append(checks, dependencyCheck{fmt.Sprintf("target %d", i+1), func(ctx context.Context) error {
+				if target.Type == fediverseMisskey {
+					return checkReachable(ctx, target.Server)
+				}
+				return checkMastodonHealth(ctx, target.Server)
+			}})
+		}
+	}
+
+	return checks
+}
+
+// CheckDependencies probes everything the configured run depends on, for
+// -health-once, writes a line per dependency to w, and reports whether all
+// of them are reachable
+func CheckDependencies(ctx context.Context, w io.Writer, config *Config) bool {
+	return checkHealth(ctx, w, dependencyChecks(config))
+}
+
+// checkHealth runs every dependency check, writes a line per dependency to
+// w, and reports whether all of them passed
+func checkHealth(ctx context.Context, w io.Writer, checks []dependencyCheck) bool {
+	healthy := true
+	for _, dep := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, healthTimeout)
+		err := dep.check(checkCtx)
+		cancel()
+
+		if err != nil {
+			healthy = false
+			fmt.Fprintf(w, "%-12s FAIL %v\n", dep.name, err)
+		} else {
+			fmt.Fprintf(w, "%-12s ok\n", dep.name)
+		}
+	}
+	return healthy
+}
+
+// checkReachable reports whether server answers HTTP requests without a
+// server error. Any other response, even a 404, proves it is up.
+func checkReachable(ctx context.Context, server string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, server, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", server, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s returned status %d", server, resp.StatusCode)
+	}
+	return nil
+}
+
+// checkReadable reports whether the file at path can be opened
+func checkReadable(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// checkWritable reports whether the file at path can be written, without
+// changing it. A file that doesn't exist yet is probed by creating and
+// removing a temporary file next to it.
+func checkWritable(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err == nil {
+		return file.Close()
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	probe, err := os.CreateTemp(filepath.Dir(path), ".pocket2fedi-health-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,173 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/motemen/go-pocket/api"
+)
+
+func TestCheckHealth_MixedDependencies(t *testing.T) {
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer mockPocketServer.Close()
+
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockMastodonServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	config := &Config{
+		Source:          sourcePocket,
+		Output:          outputMastodon,
+		MastodonServer:  mockMastodonServer.URL,
+		DeniedItemsFile: filepath.Join(t.TempDir(), "missing"),
+	}
+
+	var report strings.Builder
+	if checkHealth(context.Background(), &report, dependencyChecks(config)) {
+		t.Errorf("Expected the health check to fail, got:\n%s", report.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a line per dependency, got:\n%s", report.String())
+	}
+	for i, want := range []string{"pocket       ok", "denied-items FAIL", "mastodon     FAIL"} {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("Expected line %d to start with '%s', got '%s'", i, want, lines[i])
+		}
+	}
+}
+
+func TestCheckHealth_AllHealthy(t *testing.T) {
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{
+		Source:         sourceWallabag,
+		Output:         outputMastodon,
+		WallabagServer: mockMastodonServer.URL,
+		MastodonServer: mockMastodonServer.URL,
+	}
+
+	var report strings.Builder
+	if !checkHealth(context.Background(), &report, dependencyChecks(config)) {
+		t.Errorf("Expected the health check to pass, got:\n%s", report.String())
+	}
+}
+
+func TestCheckHealth_Misskey(t *testing.T) {
+	var paths []string
+	mockMisskeyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockMisskeyServer.Close()
+
+	config := &Config{
+		Source:         sourceJSONLines,
+		JSONLinesInput: "-",
+		Output:         outputMastodon,
+		FediverseType:  fediverseMisskey,
+		MastodonServer: mockMisskeyServer.URL,
+	}
+
+	var report strings.Builder
+	if !checkHealth(context.Background(), &report, dependencyChecks(config)) {
+		t.Errorf("Expected the health check to pass, got:\n%s", report.String())
+	}
+	if !strings.Contains(report.String(), "misskey      ok") {
+		t.Errorf("Expected a misskey line, got:\n%s", report.String())
+	}
+	// Misskey has no /health endpoint, so only the server itself is probed
+	if len(paths) != 1 || paths[0] != "/" {
+		t.Errorf("Expected a single request for '/', got %q", paths)
+	}
+}
+
+func TestCheckHealth_StateStore(t *testing.T) {
+	dir := t.TempDir()
+
+	config := &Config{
+		Source:         sourceJSONLines,
+		JSONLinesInput: "-",
+		StateFile:      filepath.Join(dir, "state.json"),
+	}
+
+	var report strings.Builder
+	if !checkHealth(context.Background(), &report, dependencyChecks(config)) {
+		t.Errorf("Expected the health check to pass, got:\n%s", report.String())
+	}
+	if !strings.HasPrefix(report.String(), "state        ok") {
+		t.Errorf("Expected a state line, got:\n%s", report.String())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read state directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected the probe to leave no files behind, got %d", len(entries))
+	}
+
+	config.StateBackend = stateBackendSQLite
+	config.StateDBPath = filepath.Join(dir, "missing", "state.db")
+
+	report.Reset()
+	if checkHealth(context.Background(), &report, dependencyChecks(config)) {
+		t.Errorf("Expected the health check to fail, got:\n%s", report.String())
+	}
+	if !strings.HasPrefix(report.String(), "state        FAIL") {
+		t.Errorf("Expected a failing state line, got:\n%s", report.String())
+	}
+}
+
+func TestCheckHealth_Targets(t *testing.T) {
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockMastodonServer.Close()
+
+	mockTargetServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockTargetServer.Close()
+
+	config := &Config{
+		Source:         sourceJSONLines,
+		JSONLinesInput: "-",
+		Output:         outputMastodon,
+		MastodonServer: mockMastodonServer.URL,
+		Targets: []Target{
+			{Server: mockMastodonServer.URL, Token: "token"},
+			{Type: fediverseMisskey, Server: mockTargetServer.URL, Token: "token"},
+		},
+	}
+
+	var report strings.Builder
+	if checkHealth(context.Background(), &report, dependencyChecks(config)) {
+		t.Errorf("Expected the health check to fail, got:\n%s", report.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a line per dependency, got:\n%s", report.String())
+	}
+	for i, want := range []string{"mastodon     ok", "target 1     ok", "target 2     FAIL"} {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("Expected line %d to start with '%s', got '%s'", i, want, lines[i])
+		}
+	}
+}
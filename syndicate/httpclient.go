@@ -0,0 +1,87 @@
+package syndicate
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/motemen/go-pocket/api"
+)
+
+// defaultHTTPTimeoutSeconds is used when HTTP_TIMEOUT_SECONDS isn't set
+const defaultHTTPTimeoutSeconds = 10
+
+// httpTimeout bounds each outgoing request. Zero means no timeout.
+var httpTimeout = defaultHTTPTimeoutSeconds * time.Second
+
+// httpTransport carries outgoing requests through the configured proxy, if
+// any
+var httpTransport http.RoundTripper = http.DefaultTransport
+
+// ConfigureHTTPClients applies the configured timeout and proxy to the
+// Pocket client and every other client made with httpClient
+func ConfigureHTTPClients(config *Config) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc(config.Proxy)
+	httpTransport = transport
+
+	httpTimeout = time.Duration(config.HTTPTimeoutSeconds) * time.Second
+	api.DefaultClient = httpClient()
+}
+
+// httpConfigFromEnv reads just HTTP_TIMEOUT_SECONDS and POCKET2FEDI_PROXY,
+// for subcommands that run before there is a full config
+func httpConfigFromEnv() (*Config, error) {
+	config := &Config{HTTPTimeoutSeconds: defaultHTTPTimeoutSeconds, Proxy: os.Getenv("POCKET2FEDI_PROXY")}
+	if value := os.Getenv("HTTP_TIMEOUT_SECONDS"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid HTTP_TIMEOUT_SECONDS %q: must be a non-negative integer", value)
+		}
+		config.HTTPTimeoutSeconds = seconds
+	}
+	if config.Proxy != "" {
+		if _, err := parseProxyURL(config.Proxy); err != nil {
+			return nil, fmt.Errorf("invalid POCKET2FEDI_PROXY: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// httpClient returns a client with the configured timeout and proxy
+func httpClient() *http.Client {
+	return &http.Client{Timeout: httpTimeout, Transport: httpTransport}
+}
+
+// proxyFunc returns the transport Proxy function for proxy, falling back to
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY when it is empty
+func proxyFunc(proxy string) func(*http.Request) (*url.URL, error) {
+	if proxy == "" {
+		return http.ProxyFromEnvironment
+	}
+	u, err := parseProxyURL(proxy)
+	if err != nil {
+		return func(*http.Request) (*url.URL, error) { return nil, err }
+	}
+	return http.ProxyURL(u)
+}
+
+// parseProxyURL checks that raw is an http, https or socks5 proxy URL
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("must start with http://, https:// or socks5://, e.g. socks5://localhost:1080")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("must include a host name")
+	}
+	return u, nil
+}
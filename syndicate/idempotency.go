@@ -0,0 +1,44 @@
+package syndicate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// idempotencyKeyContext is the context key for the Idempotency-Key of a post
+type idempotencyKeyContext struct{}
+
+// idempotencyKey returns a stable key for posting item with the template
+// tmpl. Mastodon remembers the key for an hour and returns the original
+// status for a repeated request, so a save that was posted just before a
+// crash isn't posted twice on the next run. Saves without an item ID are keyed
+// by URL.
+func idempotencyKey(item *PocketItem, tmpl string) string {
+	id := item.ItemID
+	if id == "" {
+		id = item.URL
+	}
+	sum := sha256.Sum256([]byte(id + "\x00" + tmpl))
+	return hex.EncodeToString(sum[:])
+}
+
+// withIdempotencyKey returns a context whose requests carry key as their
+// Idempotency-Key header
+func withIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContext{}, key)
+}
+
+// setIdempotencyKey returns req with the Idempotency-Key header from its
+// context, if there is one. The Mastodon client doesn't let us set headers,
+// so the transport adds it.
+func setIdempotencyKey(req *http.Request) *http.Request {
+	key, ok := req.Context().Value(idempotencyKeyContext{}).(string)
+	if !ok || key == "" || req.Method != http.MethodPost {
+		return req
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Idempotency-Key", key)
+	return req
+}
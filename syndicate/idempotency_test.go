@@ -0,0 +1,63 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	item := &PocketItem{ItemID: "123", Title: "Test Article", URL: "https://example.com/article"}
+
+	key := idempotencyKey(item, defaultStatusTemplate)
+	if len(key) != 64 {
+		t.Errorf("Expected a 64 character hex key, got %q", key)
+	}
+	if again := idempotencyKey(&PocketItem{ItemID: "123", Title: "Retitled", URL: "https://example.com/moved"}, defaultStatusTemplate); again != key {
+		t.Errorf("Expected the same item ID and template to give the same key, got %q and %q", key, again)
+	}
+	if other := idempotencyKey(&PocketItem{ItemID: "456"}, defaultStatusTemplate); other == key {
+		t.Errorf("Expected a different item to give a different key")
+	}
+	if other := idempotencyKey(item, "{{.Title}} {{.URL}}"); other == key {
+		t.Errorf("Expected a different template to give a different key")
+	}
+
+	// Without an item ID the URL identifies the save
+	noID := &PocketItem{URL: "https://example.com/article"}
+	if idempotencyKey(noID, defaultStatusTemplate) != idempotencyKey(&PocketItem{URL: "https://example.com/article"}, defaultStatusTemplate) {
+		t.Errorf("Expected saves without an ID to be keyed by URL")
+	}
+}
+
+func TestPostSaves_IdempotencyKeyHeader(t *testing.T) {
+	var mu sync.Mutex
+	keys := map[string]string{}
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		keys[r.PostForm.Get("status")] = r.Header.Get("Idempotency-Key")
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	saves := []*PocketItem{
+		{ItemID: "123", Title: "Test Article 1", URL: "https://example.com/article1"},
+		{ItemID: "456", Title: "Test Article 2", URL: "https://example.com/article2"},
+	}
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Output: outputMastodon, StatusTemplate: "{{.Title}}"}
+	if _, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, saves); err != nil || len(errs) != 0 {
+		t.Fatalf("postSaves failed: %v %v", err, errs)
+	}
+
+	for _, save := range saves {
+		expected := idempotencyKey(save, "{{.Title}}")
+		if keys[save.Title] != expected {
+			t.Errorf("Expected Idempotency-Key %q for '%s', got %q", expected, save.Title, keys[save.Title])
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-mastodon"
+)
+
+// instanceLimits are the posting limits an instance advertises in its
+// configuration
+type instanceLimits struct {
+	MaxCharacters      int
+	MaxPollOptions     int
+	MaxPollOptionChars int
+	MinPollExpiration  time.Duration
+	MaxPollExpiration  time.Duration
+}
+
+// defaultInstanceLimits are conservative limits for instances that don't
+// publish their configuration
+var defaultInstanceLimits = instanceLimits{
+	MaxCharacters:      500,
+	MaxPollOptions:     maxPollOptions,
+	MaxPollOptionChars: maxPollOptionLength,
+	MinPollExpiration:  minPollExpiry,
+	MaxPollExpiration:  maxPollExpiry,
+}
+
+// fetchInstanceLimits queries the instance configuration once, falling back
+// to defaultInstanceLimits for any value the instance doesn't report
+func fetchInstanceLimits(ctx context.Context, server, accessToken string) (*instanceLimits, error) {
+	client := mastodon.NewClient(&mastodon.Config{
+		Server:      server,
+		AccessToken: accessToken,
+	})
+	client.Timeout = httpTimeout
+	client.Transport = httpTransport
+
+	instance, err := client.GetInstance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Mastodon instance configuration: %w", err)
+	}
+
+	return parseInstanceLimits(instance.Configuration), nil
+}
+
+// parseInstanceLimits reads posting limits out of an instance configuration
+func parseInstanceLimits(config *mastodon.InstanceConfig) *instanceLimits {
+	limits := defaultInstanceLimits
+	if config == nil {
+		return &limits
+	}
+
+	if config.Statuses != nil {
+		statuses := *config.Statuses
+		if value, ok := statuses["max_characters"]; ok && value > 0 {
+			limits.MaxCharacters = value
+		}
+	}
+
+	if config.Polls != nil {
+		polls := *config.Polls
+		if value, ok := polls["max_options"]; ok && value > 0 {
+			limits.MaxPollOptions = value
+		}
+		if value, ok := polls["max_characters_per_option"]; ok && value > 0 {
+			limits.MaxPollOptionChars = value
+		}
+		if value, ok := polls["min_expiration"]; ok && value > 0 {
+			limits.MinPollExpiration = time.Duration(value) * time.Second
+		}
+		if value, ok := polls["max_expiration"]; ok && value > 0 {
+			limits.MaxPollExpiration = time.Duration(value) * time.Second
+		}
+	}
+
+	return &limits
+}
+
+// fitPoll returns a copy of poll adjusted to the instance limits: extra
+// options are dropped, long options are cut short, and the expiry is clamped
+func (l *instanceLimits) fitPoll(poll *mastodon.TootPoll) *mastodon.TootPoll {
+	if poll == nil {
+		return nil
+	}
+
+	fitted := *poll
+	fitted.Options = nil
+	for _, option := range poll.Options {
+		if len(fitted.Options) == l.MaxPollOptions {
+			break
+		}
+		if runes := []rune(option); len(runes) > l.MaxPollOptionChars {
+			option = string(runes[:l.MaxPollOptionChars])
+		}
+		fitted.Options = append(fitted.Options, option)
+	}
+
+	expiry := time.Duration(fitted.ExpiresInSeconds) * time.Second
+	if expiry < l.MinPollExpiration {
+		expiry = l.MinPollExpiration
+	}
+	if expiry > l.MaxPollExpiration {
+		expiry = l.MaxPollExpiration
+	}
+	fitted.ExpiresInSeconds = int64(expiry.Seconds())
+
+	return &fitted
+}
@@ -0,0 +1,80 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-mastodon"
+)
+
+func TestFetchInstanceLimits(t *testing.T) {
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/instance" {
+			t.Errorf("Unexpected request path '%s'", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"uri": "mastodon.example",
+			"title": "Example",
+			"configuration": {
+				"statuses": {"max_characters": 500, "max_media_attachments": 6, "characters_reserved_per_url": 23},
+				"media_attachments": {"image_size_limit": 10485760},
+				"polls": {"max_options": 3, "max_characters_per_option": 25, "min_expiration": 600, "max_expiration": 86400}
+			}
+		}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	limits, err := fetchInstanceLimits(context.Background(), mockMastodonServer.URL, "test_mastodon_token")
+	if err != nil {
+		t.Fatalf("fetchInstanceLimits failed: %v", err)
+	}
+
+	expected := &instanceLimits{
+		MaxCharacters:      500,
+		MaxPollOptions:     3,
+		MaxPollOptionChars: 25,
+		MinPollExpiration:  10 * time.Minute,
+		MaxPollExpiration:  24 * time.Hour,
+	}
+	if !reflect.DeepEqual(limits, expected) {
+		t.Errorf("Expected limits %+v, got %+v", expected, limits)
+	}
+}
+
+func TestParseInstanceLimits_OlderInstance(t *testing.T) {
+	limits := parseInstanceLimits(nil)
+	if *limits != defaultInstanceLimits {
+		t.Errorf("Expected default limits %+v, got %+v", defaultInstanceLimits, *limits)
+	}
+}
+
+func TestFitPoll(t *testing.T) {
+	limits := &instanceLimits{
+		MaxPollOptions:     2,
+		MaxPollOptionChars: 5,
+		MinPollExpiration:  10 * time.Minute,
+		MaxPollExpiration:  time.Hour,
+	}
+
+	poll := &mastodon.TootPoll{Options: []string{"Absolutely", "No", "Later"}, ExpiresInSeconds: 86400}
+	fitted := limits.fitPoll(poll)
+
+	if !reflect.DeepEqual(fitted.Options, []string{"Absol", "No"}) {
+		t.Errorf("Expected options [Absol No], got %v", fitted.Options)
+	}
+	if fitted.ExpiresInSeconds != 3600 {
+		t.Errorf("Expected expiry clamped to 3600, got %d", fitted.ExpiresInSeconds)
+	}
+	if len(poll.Options) != 3 {
+		t.Errorf("fitPoll should not modify the configured poll")
+	}
+
+	if limits.fitPoll(nil) != nil {
+		t.Errorf("Expected no poll when none is configured")
+	}
+}
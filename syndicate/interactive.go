@@ -0,0 +1,93 @@
+package syndicate
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// prompter asks on a terminal before each status is posted. A nil prompter
+// approves everything.
+type prompter struct {
+	lines   <-chan string
+	out     io.Writer
+	postAll bool
+	skipAll bool
+
+	// declined counts the statuses that were answered no or skipped
+	declined int
+}
+
+// newPrompter reads answers from in, one per line, and writes prompts to out
+func newPrompter(in io.Reader, out io.Writer) *prompter {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return &prompter{lines: lines, out: out}
+}
+
+// IsTerminal reports whether f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// confirm shows the rendered status and asks whether to post it. Answering
+// skip-all or post-all applies to every remaining status without asking.
+func (p *prompter) confirm(ctx context.Context, status string) (bool, error) {
+	if p == nil || p.postAll {
+		return true, nil
+	}
+	if p.skipAll {
+		p.declined++
+		return false, nil
+	}
+
+	fmt.Fprintf(p.out, "\n%s\n", status)
+	for {
+		fmt.Fprint(p.out, "Post this? [y]es/[n]o/[s]kip-all/post-[a]ll: ")
+
+		var answer string
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case line, ok := <-p.lines:
+			if !ok {
+				return false, errors.New("input closed before an answer was given")
+			}
+			answer = strings.ToLower(strings.TrimSpace(line))
+		}
+
+		switch answer {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			p.declined++
+			return false, nil
+		case "s", "skip-all":
+			p.skipAll = true
+			p.declined++
+			return false, nil
+		case "a", "post-all":
+			p.postAll = true
+			return true, nil
+		}
+	}
+}
+
+// declinedAny reports whether any status was declined at the prompt
+func (p *prompter) declinedAny() bool {
+	return p != nil && p.declined > 0
+}
@@ -0,0 +1,124 @@
+package syndicate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrompter_ScriptedAnswers(t *testing.T) {
+	var output strings.Builder
+	prompt := newPrompter(strings.NewReader("maybe\ny\nn\na\n"), &output)
+
+	// "maybe" isn't an answer, so the first status is asked about twice
+	expected := []bool{true, false, true, true}
+	for i, want := range expected {
+		ok, err := prompt.confirm(context.Background(), "New Pocket save: Test Article - https://example.com/article")
+		if err != nil {
+			t.Fatalf("confirm %d failed: %v", i, err)
+		}
+		if ok != want {
+			t.Errorf("Answer %d: expected %v, got %v", i, want, ok)
+		}
+	}
+
+	if count := strings.Count(output.String(), "Post this?"); count != 4 {
+		t.Errorf("Expected 4 prompts (post-all stops asking), got %d:\n%s", count, output.String())
+	}
+	if !strings.Contains(output.String(), "https://example.com/article") {
+		t.Errorf("Expected the rendered status to be shown, got:\n%s", output.String())
+	}
+}
+
+func TestPrompter_SkipAll(t *testing.T) {
+	prompt := newPrompter(strings.NewReader("skip-all\n"), &strings.Builder{})
+
+	for i := 0; i < 3; i++ {
+		ok, err := prompt.confirm(context.Background(), "status")
+		if err != nil {
+			t.Fatalf("confirm failed: %v", err)
+		}
+		if ok {
+			t.Errorf("Expected status %d to be skipped", i)
+		}
+	}
+}
+
+func TestPrompter_Cancelled(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	prompt := newPrompter(reader, &strings.Builder{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := prompt.confirm(ctx, "status"); err == nil {
+		t.Errorf("confirm should have failed once the context was cancelled")
+	}
+}
+
+func TestPrompter_InputClosed(t *testing.T) {
+	prompt := newPrompter(strings.NewReader(""), &strings.Builder{})
+	if _, err := prompt.confirm(context.Background(), "status"); err == nil {
+		t.Errorf("confirm should have failed when input ends without an answer")
+	}
+}
+
+func TestPostSaves_Interactive(t *testing.T) {
+	var statuses []string
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		statuses = append(statuses, r.PostForm.Get("status"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token"}
+	prompt := newPrompter(strings.NewReader("n\ny\n"), &strings.Builder{})
+	posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, prompt, nil, 0, []*PocketItem{
+		{Title: "Test Article 1", URL: "https://example.com/article1"},
+		{Title: "Test Article 2", URL: "https://example.com/article2"},
+	})
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+	if posted != 1 || len(errs) != 0 {
+		t.Errorf("Expected 1 posted and 0 failed, got %d and %d", posted, len(errs))
+	}
+	if len(statuses) != 1 || !strings.Contains(statuses[0], "Test Article 2") {
+		t.Errorf("Expected only Test Article 2 to be posted, got %v", statuses)
+	}
+}
+
+func TestRun_DeclinedSaveFetchedAgain(t *testing.T) {
+	config := &Config{FediverseType: fediverseMisskey, Output: outputMastodon, ImageItemPolicy: imageItemsPost, StatusTemplate: defaultStatusTemplate}
+	source := &FakeSource{Items: []*PocketItem{
+		{ItemID: "1", Title: "Test Article 1", URL: "https://example.com/article1", TimeAdded: time.Unix(1704067200, 0)},
+		{ItemID: "2", Title: "Test Article 2", URL: "https://example.com/article2", TimeAdded: time.Unix(1704070800, 0)},
+	}}
+	store := newMemoryStateStore()
+
+	prompt := newPrompter(strings.NewReader("n\ny\n"), &strings.Builder{})
+	if result := run(context.Background(), config, source, &FakePoster{}, prompt, store); result.Posted != 1 {
+		t.Fatalf("Expected 1 post on the first run, got %+v", result)
+	}
+	if !store.LastSync().IsZero() {
+		t.Errorf("Expected the last sync not to move past a declined save, got %v", store.LastSync())
+	}
+
+	// The next run asks for the same saves and offers the declined one again
+	poster := &FakePoster{}
+	if result := run(context.Background(), config, source, poster, nil, store); result.Posted != 1 {
+		t.Fatalf("Expected the declined save to be posted on the second run, got %+v", result)
+	}
+	if !source.Since.IsZero() {
+		t.Errorf("Expected the second run to fetch from the start, got since %v", source.Since)
+	}
+	if store.LastSync().Unix() != 1704070800 {
+		t.Errorf("Expected last sync 1704070800 once nothing was declined, got %d", store.LastSync().Unix())
+	}
+}
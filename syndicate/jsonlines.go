@@ -0,0 +1,109 @@
+package syndicate
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Output targets for rendered statuses
+const (
+	outputMastodon = "mastodon"
+	outputJSON     = "json"
+)
+
+// jsonOutput is where records go under the json output, overridable in tests
+var jsonOutput io.Writer = os.Stdout
+
+// postRecord is one rendered post written as a line of JSON. The same
+// records are read back by the json-lines source, which re-renders Status
+// from the item fields.
+type postRecord struct {
+	ItemID    string    `json:"item_id,omitempty"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Excerpt   string    `json:"excerpt,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	IsArticle bool      `json:"is_article"`
+	HasImage  int       `json:"has_image,omitempty"`
+	HasVideo  int       `json:"has_video,omitempty"`
+	ImageURL  string    `json:"image_url,omitempty"`
+	TimeAdded time.Time `json:"time_added"`
+	Status    string    `json:"status"`
+}
+
+// writeRecord writes save and its rendered status to w as a line of JSON
+func writeRecord(w io.Writer, save *PocketItem, status string) error {
+	record := postRecord{
+		ItemID:    save.ItemID,
+		Title:     save.Title,
+		URL:       save.URL,
+		Excerpt:   save.Excerpt,
+		Tags:      save.Tags,
+		IsArticle: save.IsArticle,
+		HasImage:  save.HasImage,
+		HasVideo:  save.HasVideo,
+		ImageURL:  save.ImageURL,
+		TimeAdded: save.TimeAdded,
+		Status:    status,
+	}
+	if err := json.NewEncoder(w).Encode(record); err != nil {
+		return fmt.Errorf("failed to write JSON record: %w", err)
+	}
+	return nil
+}
+
+// jsonLinesFetcher reads saves from records written by the json output,
+// one per line, from a file or from stdin when path is "-"
+type jsonLinesFetcher struct {
+	path string
+}
+
+// Fetch returns every record in the input as a save. The input is replayed
+// as is, so since is ignored.
+func (f *jsonLinesFetcher) Fetch(ctx context.Context, since time.Time) ([]*PocketItem, error) {
+	var input io.Reader = os.Stdin
+	if f.path != "-" {
+		file, err := os.Open(f.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open JSON lines input: %w", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var saves []*PocketItem
+	scanner := bufio.NewScanner(input)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record postRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON lines input at line %d: %w", line, err)
+		}
+		saves = append(saves, &PocketItem{
+			ItemID:      record.ItemID,
+			Title:       record.Title,
+			URL:         record.URL,
+			ResolvedURL: record.URL,
+			Excerpt:     record.Excerpt,
+			Tags:        record.Tags,
+			IsArticle:   record.IsArticle,
+			HasImage:    record.HasImage,
+			HasVideo:    record.HasVideo,
+			ImageURL:    record.ImageURL,
+			TimeAdded:   record.TimeAdded,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON lines input: %w", err)
+	}
+
+	logger.Info(fmt.Sprintf("Successfully read %d saves from JSON lines input", len(saves)), "count", len(saves))
+	return saves, nil
+}
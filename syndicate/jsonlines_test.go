@@ -0,0 +1,72 @@
+package syndicate
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONOutput_RoundTrip(t *testing.T) {
+	var output bytes.Buffer
+	originalOutput := jsonOutput
+	jsonOutput = &output
+	defer func() { jsonOutput = originalOutput }()
+
+	saves := []*PocketItem{
+		{ItemID: "123", Title: "Test Article 1", URL: "https://example.com/article1", IsArticle: true, TimeAdded: time.Unix(1704067200, 0).UTC()},
+		{ItemID: "456", Title: "", URL: "https://example.com/photo.jpg", HasImage: 2},
+	}
+
+	// No Mastodon server is configured, so posting would fail
+	config := &Config{Output: outputJSON}
+	posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, saves)
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+	if posted != 2 || len(errs) != 0 {
+		t.Errorf("Expected 2 written and 0 failed, got %d and %d", posted, len(errs))
+	}
+	if lines := strings.Count(output.String(), "\n"); lines != 2 {
+		t.Errorf("Expected 2 lines of output, got %d:\n%s", lines, output.String())
+	}
+	if !strings.Contains(output.String(), `"status":"New Pocket save: Test Article 1 - https://example.com/article1"`) {
+		t.Errorf("Expected the rendered status in the output, got:\n%s", output.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "posts.jsonl")
+	if err := os.WriteFile(path, output.Bytes(), 0o644); err != nil {
+		t.Fatalf("Failed to write JSON lines file: %v", err)
+	}
+
+	replayed, err := (&jsonLinesFetcher{path: path}).Fetch(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(replayed) != len(saves) {
+		t.Fatalf("Expected %d replayed saves, got %d", len(saves), len(replayed))
+	}
+	for i, save := range replayed {
+		want := *saves[i]
+		want.ResolvedURL = want.URL
+		if !reflect.DeepEqual(*save, want) {
+			t.Errorf("Expected replayed save %+v, got %+v", want, *save)
+		}
+	}
+}
+
+func TestJSONLinesFetcher_Malformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.jsonl")
+	if err := os.WriteFile(path, []byte("{\"title\": \"ok\", \"url\": \"https://example.com\"}\nnot json\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write JSON lines file: %v", err)
+	}
+
+	_, err := (&jsonLinesFetcher{path: path}).Fetch(context.Background(), time.Time{})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error pointing at line 2, got %v", err)
+	}
+}
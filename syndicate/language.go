@@ -0,0 +1,138 @@
+package syndicate
+
+import (
+	"strings"
+	"unicode"
+)
+
+// scriptLanguages maps writing systems used by essentially one language to
+// its ISO 639-1 code. Han is handled separately since Japanese mixes it with
+// kana.
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Thai, "th"},
+	{unicode.Cyrillic, "ru"},
+}
+
+// stopwords are short, very common words of each language written in the
+// Latin alphabet. Words shared by several languages count for each of them.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "on", "was", "this", "are", "you", "how", "what", "why", "from", "your", "be", "by", "at", "an", "we", "not"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "ein", "eine", "den", "von", "zu", "für", "auf", "sich", "auch", "wie", "warum", "dem", "des", "im", "wir", "sind", "oder"},
+	"fr": {"le", "les", "et", "des", "est", "une", "un", "du", "pour", "dans", "que", "qui", "pas", "sur", "au", "avec", "ce", "il", "sont", "comment", "pourquoi", "nous", "vous", "la"},
+	"es": {"el", "los", "las", "y", "es", "una", "por", "para", "con", "que", "del", "se", "no", "como", "más", "pero", "porque", "son", "está", "la", "lo", "en"},
+	"it": {"il", "gli", "e", "è", "di", "che", "per", "una", "non", "con", "del", "della", "sono", "come", "perché", "anche", "nel", "alla", "la", "un", "si"},
+	"pt": {"o", "os", "as", "e", "é", "de", "do", "da", "que", "não", "uma", "um", "para", "com", "em", "por", "mais", "como", "são", "dos", "das", "você"},
+	"nl": {"de", "het", "een", "en", "van", "is", "niet", "op", "dat", "met", "voor", "zijn", "ook", "hoe", "waarom", "wat", "die", "te", "je", "wij"},
+}
+
+// stopwordIndex maps each stopword to the languages it belongs to
+var stopwordIndex = func() map[string][]string {
+	index := map[string][]string{}
+	for language, words := range stopwords {
+		for _, word := range words {
+			index[word] = append(index[word], language)
+		}
+	}
+	return index
+}()
+
+// detectLanguage guesses the ISO 639-1 code of text. Text mostly in a script
+// that belongs to one language is detected by script; Latin text by counting
+// common words. It returns "" when the text gives too little to go on or two
+// languages score the same.
+func detectLanguage(text string) string {
+	if language := detectScriptLanguage(text); language != "" {
+		return language
+	}
+
+	scores := map[string]int{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		for _, language := range stopwordIndex[word] {
+			scores[language]++
+		}
+	}
+
+	best, bestScore, runnerUp := "", 0, 0
+	for language, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, runnerUp = language, score, bestScore
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+	// One shared word is not enough to tell languages apart
+	if bestScore < 2 || bestScore == runnerUp {
+		return ""
+	}
+	return best
+}
+
+// detectScriptLanguage returns the language of text if most of its letters
+// are in a script used by one language, or "" otherwise
+func detectScriptLanguage(text string) string {
+	counts := map[string]int{}
+	var letters, han, kana int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		default:
+			for _, s := range scriptLanguages {
+				if unicode.Is(s.script, r) {
+					counts[s.language]++
+					break
+				}
+			}
+		}
+	}
+
+	switch {
+	case letters == 0:
+		return ""
+	case kana > 0 && (kana+han)*2 > letters:
+		return "ja"
+	case han*2 > letters:
+		return "zh"
+	}
+	for language, count := range counts {
+		if count*2 > letters {
+			return language
+		}
+	}
+	return ""
+}
+
+// isLanguageCode reports whether code looks like an ISO 639-1 code: two
+// lowercase letters
+func isLanguageCode(code string) bool {
+	return len(code) == 2 && code[0] >= 'a' && code[0] <= 'z' && code[1] >= 'a' && code[1] <= 'z'
+}
+
+// statusLanguage returns the language to tag save's status with: the one
+// detected from its title and excerpt when detection is enabled, or the
+// configured default
+func statusLanguage(config *Config, save *PocketItem) string {
+	if config.DetectLanguage {
+		if language := detectLanguage(save.Title + "\n" + save.Excerpt); language != "" {
+			return language
+		}
+	}
+	return config.DefaultLanguage
+}
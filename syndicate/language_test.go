@@ -0,0 +1,94 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"How to write the perfect README for your open source project", "en"},
+		{"Warum die Bahn nicht pünktlich ist und was sich ändern muss", "de"},
+		{"Comment les villes se préparent pour les vagues de chaleur", "fr"},
+		{"Por qué los precios de la vivienda no dejan de subir en las ciudades", "es"},
+		{"Perché il lavoro da remoto è qui per restare, anche nel 2024", "it"},
+		{"Como a inteligência artificial está mudando o jornalismo e os jornais", "pt"},
+		{"Waarom het klimaat in Nederland niet meer is wat het was", "nl"},
+		{"Почему важно высыпаться", "ru"},
+		{"東京の新しい美術館がオープンしました", "ja"},
+		{"人工智能如何改变新闻业", "zh"},
+		{"서울의 새로운 박물관", "ko"},
+		// Too little to go on
+		{"Kubernetes", ""},
+		{"Go 1.22", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := detectLanguage(tt.text); got != tt.expected {
+			t.Errorf("detectLanguage(%q): expected %q, got %q", tt.text, tt.expected, got)
+		}
+	}
+}
+
+func TestStatusLanguage(t *testing.T) {
+	english := &PocketItem{Title: "The case for boring technology", Excerpt: "Why you should pick the tools you know."}
+	unclear := &PocketItem{Title: "Kubernetes"}
+
+	tests := []struct {
+		name     string
+		config   *Config
+		save     *PocketItem
+		expected string
+	}{
+		{"detected", &Config{DetectLanguage: true, DefaultLanguage: "de"}, english, "en"},
+		{"uncertain falls back", &Config{DetectLanguage: true, DefaultLanguage: "de"}, unclear, "de"},
+		{"uncertain without default", &Config{DetectLanguage: true}, unclear, ""},
+		{"detection off", &Config{DefaultLanguage: "de"}, english, "de"},
+	}
+
+	for _, tt := range tests {
+		if got := statusLanguage(tt.config, tt.save); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestPostSaves_DetectLanguage(t *testing.T) {
+	languages := map[string]string{}
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		languages[r.PostForm.Get("status")] = r.PostForm.Get("language")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{
+		MastodonServer:  mockMastodonServer.URL,
+		MastodonToken:   "test_mastodon_token",
+		Output:          outputMastodon,
+		StatusTemplate:  "{{.Title}}",
+		DetectLanguage:  true,
+		DefaultLanguage: "en",
+	}
+	_, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, []*PocketItem{
+		{Title: "Warum die Bahn nicht pünktlich ist", URL: "https://example.com/bahn"},
+		{Title: "Kubernetes", URL: "https://example.com/k8s"},
+	})
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("postSaves failed: %v %v", err, errs)
+	}
+
+	if languages["Warum die Bahn nicht pünktlich ist"] != "de" {
+		t.Errorf("Expected the German title to be posted as de, got %q", languages["Warum die Bahn nicht pünktlich ist"])
+	}
+	if languages["Kubernetes"] != "en" {
+		t.Errorf("Expected DEFAULT_LANGUAGE when detection is uncertain, got %q", languages["Kubernetes"])
+	}
+}
@@ -0,0 +1,82 @@
+package syndicate
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// fetchLimiter bounds outbound enrichment fetches (Wayback archiving and
+// similar lookups) with a shared concurrency cap and a per-host politeness
+// delay, so enrichment does not overwhelm the host or trip rate limits
+type fetchLimiter struct {
+	sem       chan struct{}
+	hostDelay time.Duration
+	jitter    time.Duration
+
+	mu        sync.Mutex
+	nextFetch map[string]time.Time
+}
+
+// newFetchLimiter creates a limiter allowing at most maxConcurrent fetches
+// at once, spacing fetches to the same host by hostDelay plus up to jitter
+func newFetchLimiter(maxConcurrent int, hostDelay, jitter time.Duration) *fetchLimiter {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &fetchLimiter{
+		sem:       make(chan struct{}, maxConcurrent),
+		hostDelay: hostDelay,
+		jitter:    jitter,
+		nextFetch: make(map[string]time.Time),
+	}
+}
+
+// acquire blocks until a fetch to host may start. The returned function
+// must be called once the fetch is done. A nil limiter does not limit.
+func (l *fetchLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-l.sem }
+
+	if wait := l.reserve(host); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	return release, nil
+}
+
+// reserve claims the next fetch slot for host and returns how long to wait for it
+func (l *fetchLimiter) reserve(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	start := now
+	if next, ok := l.nextFetch[host]; ok && next.After(now) {
+		start = next
+	}
+
+	spacing := l.hostDelay
+	if l.jitter > 0 {
+		spacing += rand.N(l.jitter)
+	}
+	l.nextFetch[host] = start.Add(spacing)
+
+	return start.Sub(now)
+}
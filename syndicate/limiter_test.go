@@ -0,0 +1,88 @@
+package syndicate
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchLimiter_ConcurrencyBound(t *testing.T) {
+	limiter := newFetchLimiter(3, 0, 0)
+
+	var inFlight, maxInFlight int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := limiter.acquire(context.Background(), "example.com")
+			if err != nil {
+				t.Errorf("acquire failed: %v", err)
+				return
+			}
+			defer release()
+
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				seen := atomic.LoadInt32(&maxInFlight)
+				if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > 3 {
+		t.Errorf("Expected at most 3 concurrent fetches, got %d", maxInFlight)
+	}
+	if maxInFlight < 2 {
+		t.Errorf("Expected fetches to run concurrently, got a maximum of %d", maxInFlight)
+	}
+}
+
+func TestFetchLimiter_HostDelay(t *testing.T) {
+	limiter := newFetchLimiter(4, 50*time.Millisecond, 0)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := limiter.acquire(context.Background(), "web.archive.org")
+		if err != nil {
+			t.Fatalf("acquire failed: %v", err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected same-host fetches to be spaced by the host delay, took %v", elapsed)
+	}
+
+	// A different host is not held back by the first host's schedule
+	start = time.Now()
+	release, err := limiter.acquire(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	release()
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Errorf("Expected a different host to start immediately, took %v", elapsed)
+	}
+}
+
+func TestFetchLimiter_ContextCancelled(t *testing.T) {
+	limiter := newFetchLimiter(1, 0, 0)
+	release, err := limiter.acquire(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx, "example.com"); err == nil {
+		t.Errorf("acquire should have failed once the context was cancelled")
+	}
+}
@@ -0,0 +1,78 @@
+package syndicate
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+)
+
+// Log formats for LOG_FORMAT
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// Log levels for LOG_LEVEL. Summaries of a run are info, each item's
+// progress is debug.
+const (
+	logLevelDebug = "debug"
+	logLevelInfo  = "info"
+	logLevelWarn  = "warn"
+	logLevelError = "error"
+)
+
+// slogLevels maps each LOG_LEVEL onto its slog level
+var slogLevels = map[string]slog.Level{
+	logLevelDebug: slog.LevelDebug,
+	logLevelInfo:  slog.LevelInfo,
+	logLevelWarn:  slog.LevelWarn,
+	logLevelError: slog.LevelError,
+}
+
+// logger records item-level events. In text mode it prints only the message,
+// exactly as the log.Printf lines it replaced; in JSON mode the attributes
+// become fields of the record. Until SetupLogging is called every level is
+// logged.
+var logger = slog.New(textHandler{level: slog.LevelDebug})
+
+// textHandler writes just the message of records at or above level through
+// the standard logger
+type textHandler struct {
+	level slog.Level
+}
+
+func (h textHandler) Enabled(_ context.Context, level slog.Level) bool { return level >= h.level }
+
+func (textHandler) Handle(_ context.Context, r slog.Record) error {
+	log.Print(r.Message)
+	return nil
+}
+
+func (h textHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h textHandler) WithGroup(string) slog.Handler { return h }
+
+// SetupLogging points logger at w in the given format, logging only records
+// at level or above. In JSON mode plain log calls, which are only used for
+// fatal errors, are routed through the same handler as errors, so every line
+// is JSON.
+func SetupLogging(format, level string, w io.Writer) {
+	minLevel, ok := slogLevels[level]
+	if !ok {
+		minLevel = slog.LevelError
+	}
+	if format != logFormatJSON {
+		logger = slog.New(textHandler{level: minLevel})
+		return
+	}
+
+	logger = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: minLevel}))
+	slog.SetDefault(logger)
+	slog.SetLogLoggerLevel(slog.LevelError)
+}
+
+// itemAttrs returns the fields identifying save, followed by any extra pairs
+func itemAttrs(save *PocketItem, extra ...any) []any {
+	return append([]any{"item_id", save.ItemID, "url", save.URL}, extra...)
+}
@@ -0,0 +1,176 @@
+package syndicate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLogs sets up logging in format at level into a buffer and restores
+// the standard and structured loggers when the test ends
+func captureLogs(t *testing.T, format, level string) *bytes.Buffer {
+	t.Helper()
+	oldLogger, oldDefault := logger, slog.Default()
+	oldWriter, oldFlags := log.Writer(), log.Flags()
+	oldLevel := slog.SetLogLoggerLevel(slog.LevelInfo)
+	t.Cleanup(func() {
+		logger = oldLogger
+		slog.SetDefault(oldDefault)
+		slog.SetLogLoggerLevel(oldLevel)
+		log.SetOutput(oldWriter)
+		log.SetFlags(oldFlags)
+	})
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	SetupLogging(format, level, &buf)
+	return &buf
+}
+
+// decodeLogs parses each line of buf as a JSON log record
+func decodeLogs(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected a JSON log line, got '%s': %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestSetupLogging_Text(t *testing.T) {
+	buf := captureLogs(t, logFormatText, logLevelDebug)
+
+	logger.Error("Error posting to Mastodon for 'Test Article': boom", itemAttrs(&PocketItem{ItemID: "123", URL: "https://example.com/article"}, "error", errors.New("boom"))...)
+
+	output := buf.String()
+	if !strings.HasSuffix(output, "Error posting to Mastodon for 'Test Article': boom\n") {
+		t.Errorf("Expected the plain message, got '%s'", output)
+	}
+	if strings.Contains(output, "item_id") {
+		t.Errorf("Expected no fields in text mode, got '%s'", output)
+	}
+}
+
+func TestSetupLogging_JSON(t *testing.T) {
+	buf := captureLogs(t, logFormatJSON, logLevelDebug)
+
+	logger.Error("Error posting", itemAttrs(&PocketItem{ItemID: "123", URL: "https://example.com/article"}, "error", errors.New("boom"))...)
+	log.Printf("Loaded %d posted items", 2)
+
+	records := decodeLogs(t, buf)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 log records, got %d", len(records))
+	}
+	for field, want := range map[string]string{
+		"level":   "ERROR",
+		"msg":     "Error posting",
+		"item_id": "123",
+		"url":     "https://example.com/article",
+		"error":   "boom",
+	} {
+		if records[0][field] != want {
+			t.Errorf("Expected %s '%s', got '%v'", field, want, records[0][field])
+		}
+	}
+	if records[1]["msg"] != "Loaded 2 posted items" {
+		t.Errorf("Expected plain log lines to become JSON records, got '%v'", records[1]["msg"])
+	}
+}
+
+func TestPostSaves_JSONLogs(t *testing.T) {
+	requests := 0
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	buf := captureLogs(t, logFormatJSON, logLevelDebug)
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Output: outputMastodon, StatusTemplate: defaultStatusTemplate}
+	_, _, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, []*PocketItem{
+		{ItemID: "123", Title: "Test Article 1", URL: "https://example.com/article1"},
+		{ItemID: "456", Title: "Test Article 2", URL: "https://example.com/article2"},
+	})
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+
+	records := decodeLogs(t, buf)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 log records, got %d: %s", len(records), buf.String())
+	}
+	if records[0]["level"] != "DEBUG" || records[0]["item_id"] != "123" || records[0]["url"] != "https://example.com/article1" {
+		t.Errorf("Expected a DEBUG record for item 123, got %v", records[0])
+	}
+	if records[1]["level"] != "ERROR" || records[1]["item_id"] != "456" || records[1]["error"] == nil {
+		t.Errorf("Expected an ERROR record with an error for item 456, got %v", records[1])
+	}
+}
+
+func TestSetupLogging_Levels(t *testing.T) {
+	tests := []struct {
+		level    string
+		expected []string
+	}{
+		{logLevelError, []string{"error"}},
+		{"", []string{"error"}},
+		{logLevelWarn, []string{"warn", "error"}},
+		{logLevelInfo, []string{"summary", "warn", "error"}},
+		{logLevelDebug, []string{"item", "summary", "warn", "error"}},
+	}
+
+	for _, tt := range tests {
+		for _, format := range []string{logFormatText, logFormatJSON} {
+			buf := captureLogs(t, format, tt.level)
+			log.SetFlags(0)
+
+			logger.Debug("item")
+			logger.Info("summary")
+			logger.Warn("warn")
+			logger.Error("error")
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				if format == logFormatJSON {
+					var record map[string]any
+					if err := json.Unmarshal([]byte(line), &record); err != nil {
+						t.Fatalf("Expected a JSON log line, got '%s': %v", line, err)
+					}
+					line, _ = record["msg"].(string)
+				}
+				got = append(got, line)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("LOG_LEVEL=%q, LOG_FORMAT=%s: expected %v, got %v", tt.level, format, tt.expected, got)
+			}
+		}
+	}
+}
+
+func TestSetupLogging_JSONFatalsAreErrors(t *testing.T) {
+	buf := captureLogs(t, logFormatJSON, logLevelError)
+
+	log.Printf("Error loading configuration")
+
+	records := decodeLogs(t, buf)
+	if len(records) != 1 || records[0]["level"] != "ERROR" {
+		t.Errorf("Expected plain log lines to be kept as errors, got %v", records)
+	}
+}
@@ -0,0 +1,99 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Policies for URLs too long to fit comfortably in a status
+const (
+	longURLsShorten = "shorten"
+	longURLsSkip    = "skip"
+)
+
+// shortenerTimeout bounds how long we wait for the URL shortener
+var shortenerTimeout = 10 * time.Second
+
+// shortenURL asks the shortener to shorten rawURL. The shortener is a URL
+// prefix such as "https://is.gd/create.php?format=simple&url=" that the
+// escaped URL is appended to, and must answer with the short URL as plain text.
+func shortenURL(ctx context.Context, limiter *fetchLimiter, shortener, rawURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, shortenerTimeout)
+	defer cancel()
+
+	endpoint, err := url.Parse(shortener)
+	if err != nil {
+		return "", fmt.Errorf("invalid shortener URL: %w", err)
+	}
+	release, err := limiter.acquire(ctx, endpoint.Host)
+	if err != nil {
+		return "", fmt.Errorf("gave up waiting to contact URL shortener: %w", err)
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, shortener+url.QueryEscape(rawURL), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create shortener request: %w", err)
+	}
+
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach URL shortener: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("URL shortener returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2048))
+	if err != nil {
+		return "", fmt.Errorf("failed to read shortener response: %w", err)
+	}
+	short := strings.TrimSpace(string(body))
+	parsed, err := url.Parse(short)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("URL shortener returned %q, which is not a URL", short)
+	}
+
+	return short, nil
+}
+
+// applyLongURLPolicy handles saves whose URL takes up more than
+// LongURLPercent of maxChars. Under the skip policy they are dropped; under
+// the shorten policy they keep their original URL but are posted with a
+// shortened one. A failed shortening falls back to the original URL.
+func applyLongURLPolicy(ctx context.Context, config *Config, limiter *fetchLimiter, maxChars int, saves []*PocketItem) []*PocketItem {
+	if config.LongURLPolicy == "" {
+		return saves
+	}
+
+	budget := maxChars * config.LongURLPercent / 100
+	var kept []*PocketItem
+	for _, save := range saves {
+		if len([]rune(save.URL)) <= budget {
+			kept = append(kept, save)
+			continue
+		}
+
+		switch config.LongURLPolicy {
+		case longURLsSkip:
+			logger.Debug(fmt.Sprintf("Skipping '%s': its URL is longer than %d characters", save.Title, budget), itemAttrs(save)...)
+			continue
+		case longURLsShorten:
+			short, err := shortenURL(ctx, limiter, config.ShortenerURL, save.URL)
+			if err != nil {
+				logger.Error(fmt.Sprintf("Error shortening URL for '%s', using original link: %v", save.Title, err), itemAttrs(save, "error", err)...)
+			} else {
+				save.ShortURL = short
+			}
+		}
+		kept = append(kept, save)
+	}
+	return kept
+}
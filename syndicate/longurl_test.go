@@ -0,0 +1,93 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// longURL is well over half of a 500 character status
+var longURL = "https://example.com/article?" + strings.Repeat("tracking=abcdefghij&", 15)
+
+func TestShortenURL(t *testing.T) {
+	mockShortener := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("url") != "https://example.com/article1" {
+			t.Errorf("Unexpected URL to shorten '%s'", r.URL.Query().Get("url"))
+		}
+		w.Write([]byte("https://sho.rt/abc\n"))
+	}))
+	defer mockShortener.Close()
+
+	short, err := shortenURL(context.Background(), nil, mockShortener.URL+"/create?format=simple&url=", "https://example.com/article1")
+	if err != nil {
+		t.Fatalf("shortenURL failed: %v", err)
+	}
+	if short != "https://sho.rt/abc" {
+		t.Errorf("Expected 'https://sho.rt/abc', got '%s'", short)
+	}
+}
+
+func TestShortenURL_NotAURL(t *testing.T) {
+	mockShortener := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Error: rate limit exceeded"))
+	}))
+	defer mockShortener.Close()
+
+	if _, err := shortenURL(context.Background(), nil, mockShortener.URL+"/?url=", "https://example.com/article1"); err == nil {
+		t.Errorf("shortenURL should have failed for a non-URL response")
+	}
+}
+
+func TestApplyLongURLPolicy(t *testing.T) {
+	mockShortener := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("https://sho.rt/abc"))
+	}))
+	defer mockShortener.Close()
+
+	newSaves := func() []*PocketItem {
+		return []*PocketItem{
+			{Title: "Short Article", URL: "https://example.com/article1"},
+			{Title: "Long Article", URL: longURL},
+		}
+	}
+
+	tests := []struct {
+		policy    string
+		shortener string
+		expected  []string
+	}{
+		{"", "", []string{"https://example.com/article1", longURL}},
+		{longURLsSkip, "", []string{"https://example.com/article1"}},
+		{longURLsShorten, mockShortener.URL + "/?url=", []string{"https://example.com/article1", "https://sho.rt/abc"}},
+		// A shortener failure falls back to the original link
+		{longURLsShorten, "http://127.0.0.1:0/?url=", []string{"https://example.com/article1", longURL}},
+	}
+
+	for _, tt := range tests {
+		config := &Config{LongURLPolicy: tt.policy, LongURLPercent: 50, ShortenerURL: tt.shortener}
+		saves := applyLongURLPolicy(context.Background(), config, nil, 500, newSaves())
+
+		var posted []string
+		for _, save := range saves {
+			posted = append(posted, statusLink(save))
+		}
+		if strings.Join(posted, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("Policy %q: expected links %v, got %v", tt.policy, tt.expected, posted)
+		}
+
+		// The original URL is kept for everything but the status text
+		for _, save := range saves {
+			if save.Title == "Long Article" && save.URL != longURL {
+				t.Errorf("Policy %q: expected original URL to be kept, got '%s'", tt.policy, save.URL)
+			}
+		}
+	}
+}
+
+// statusLink returns the link formatStatus would post for save
+func statusLink(save *PocketItem) string {
+	status, _ := formatStatus(save, "", "", "")
+	return strings.TrimPrefix(status, "New Pocket save: "+save.Title+" - ")
+}
@@ -0,0 +1,89 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-mastodon"
+	"github.com/motemen/go-pocket/api"
+)
+
+// maxImageBytes is the largest article image we download to attach, the
+// Mastodon default upload limit for images
+const maxImageBytes = 16 << 20
+
+// pocketImageURL returns the source of the item's first image, or "" if
+// Pocket reports none. Pocket numbers images from 1 in page order.
+func pocketImageURL(item api.Item) string {
+	if item.HasImage == api.ItemMediaAttachmentNoMedia {
+		return ""
+	}
+	first, src := 0, ""
+	for id, image := range item.Images {
+		n, err := strconv.Atoi(id)
+		imageSrc, _ := image["src"].(string)
+		if err != nil || imageSrc == "" {
+			continue
+		}
+		if src == "" || n < first {
+			first, src = n, imageSrc
+		}
+	}
+	return src
+}
+
+// fetchImage downloads the image at imageURL
+func fetchImage(ctx context.Context, imageURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image request: %w", err)
+	}
+
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("not an image: Content-Type %q", contentType)
+	}
+
+	image, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(image) > maxImageBytes {
+		return nil, fmt.Errorf("image is larger than %d bytes", maxImageBytes)
+	}
+	return image, nil
+}
+
+// uploadImage fetches the image at imageURL and uploads it to the Mastodon
+// server, returning the ID to attach it to a status with
+func uploadImage(ctx context.Context, server, accessToken, imageURL string) (mastodon.ID, error) {
+	image, err := fetchImage(ctx, imageURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch image: %w", err)
+	}
+
+	client := mastodon.NewClient(&mastodon.Config{
+		Server:      server,
+		AccessToken: accessToken,
+	})
+	client.Timeout = httpTimeout
+	client.Transport = httpTransport
+
+	attachment, err := client.UploadMediaFromBytes(ctx, image)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image to Mastodon: %w", err)
+	}
+	return attachment.ID, nil
+}
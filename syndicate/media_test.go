@@ -0,0 +1,81 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/motemen/go-pocket/api"
+)
+
+func TestPocketImageURL(t *testing.T) {
+	item := api.Item{
+		HasImage: api.ItemMediaAttachmentHasMedia,
+		Images: map[string]map[string]interface{}{
+			"2": {"image_id": "2", "src": "https://example.com/second.jpg"},
+			"1": {"image_id": "1", "src": "https://example.com/lead.jpg"},
+		},
+	}
+	if got := pocketImageURL(item); got != "https://example.com/lead.jpg" {
+		t.Errorf("Expected the first image, got '%s'", got)
+	}
+
+	item.HasImage = api.ItemMediaAttachmentNoMedia
+	if got := pocketImageURL(item); got != "" {
+		t.Errorf("Expected no image when Pocket reports none, got '%s'", got)
+	}
+}
+
+func TestPostSaves_AttachImage(t *testing.T) {
+	imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/lead.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	}))
+	defer imageServer.Close()
+
+	var uploads int
+	mediaIDs := map[string]string{}
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/media":
+			uploads++
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "media1", "type": "image"}`))
+		case "/api/v1/statuses":
+			r.ParseForm()
+			mediaIDs[r.PostForm.Get("status")] = strings.Join(r.PostForm["media_ids[]"], ",")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": "1"}`))
+		}
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Output: outputMastodon, Concurrency: 1, AttachImage: true, StatusTemplate: "{{.Title}}"}
+	posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, []*PocketItem{
+		{ItemID: "1", Title: "With Image", URL: "https://example.com/1", ImageURL: imageServer.URL + "/lead.png"},
+		{ItemID: "2", Title: "Without Image", URL: "https://example.com/2"},
+		{ItemID: "3", Title: "Missing Image", URL: "https://example.com/3", ImageURL: imageServer.URL + "/gone.png"},
+	})
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+	if posted != 3 || len(errs) != 0 {
+		t.Errorf("Expected 3 posted and 0 failed, got %d posted and %d failed", posted, len(errs))
+	}
+	if uploads != 1 {
+		t.Errorf("Expected 1 image upload, got %d", uploads)
+	}
+
+	expected := map[string]string{"With Image": "media1", "Without Image": "", "Missing Image": ""}
+	for status, want := range expected {
+		if got, ok := mediaIDs[status]; !ok || got != want {
+			t.Errorf("Status '%s': expected media IDs '%s', got '%s'", status, want, got)
+		}
+	}
+}
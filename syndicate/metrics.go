@@ -0,0 +1,133 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request latency
+// histogram
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// runMetrics counts what a run did, for the Prometheus endpoint served when
+// METRICS_ADDR is set
+type runMetrics struct {
+	mu      sync.Mutex
+	fetched int
+	posted  int
+	failed  int
+
+	// Request latency histogram: counts per bucket (not cumulative), plus
+	// the total of all observations
+	latencyCounts []int
+	latencySum    float64
+	latencyCount  int
+}
+
+// defaultMetricsDrain is how long the metrics stay up after a run when
+// METRICS_DRAIN isn't set, long enough for a 15s scrape interval to catch them
+const defaultMetricsDrain = 30 * time.Second
+
+// metrics records every run in the process
+var metrics = newRunMetrics()
+
+// newRunMetrics returns metrics with every counter at zero
+func newRunMetrics() *runMetrics {
+	return &runMetrics{latencyCounts: make([]int, len(latencyBuckets))}
+}
+
+// addRun records the saves fetched, posted, and failed by a run
+func (m *runMetrics) addRun(fetched, posted, failed int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetched += fetched
+	m.posted += posted
+	m.failed += failed
+}
+
+// observeRequest records how long a request to post a Mastodon status took
+func (m *runMetrics) observeRequest(d time.Duration) {
+	seconds := d.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			m.latencyCounts[i]++
+			break
+		}
+	}
+	m.latencySum += seconds
+	m.latencyCount++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *runMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, counter := range []struct {
+		name, help string
+		value      int
+	}{
+		{"pocket2fedi_items_fetched_total", "Saves fetched from the read-later service.", m.fetched},
+		{"pocket2fedi_items_posted_total", "Saves posted.", m.posted},
+		{"pocket2fedi_post_failures_total", "Saves that failed to post.", m.failed},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", counter.name, counter.help, counter.name, counter.name, counter.value)
+	}
+
+	const histogram = "pocket2fedi_mastodon_request_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Latency of requests to post Mastodon statuses.\n# TYPE %s histogram\n", histogram, histogram)
+	cumulative := 0
+	for i, bound := range latencyBuckets {
+		cumulative += m.latencyCounts[i]
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", histogram, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", histogram, m.latencyCount)
+	fmt.Fprintf(w, "%s_sum %g\n", histogram, m.latencySum)
+	fmt.Fprintf(w, "%s_count %d\n", histogram, m.latencyCount)
+}
+
+// startMetricsServer serves m at /metrics on addr in the background. The
+// returned server's Addr is the address actually listened on.
+func startMetricsServer(addr string, m *runMetrics) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start metrics server: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	server := &http.Server{Addr: listener.Addr().String(), Handler: mux}
+	go server.Serve(listener)
+	return server, nil
+}
+
+// ServeMetrics serves the metrics of every run in the process at /metrics on
+// addr in the background
+func ServeMetrics(addr string) (*http.Server, error) {
+	return startMetricsServer(addr, metrics)
+}
+
+// DrainMetrics keeps the metrics server up for drain so the run's results
+// can be scraped, then shuts it down. It returns early if ctx is cancelled.
+func DrainMetrics(ctx context.Context, server *http.Server, drain time.Duration) {
+	if drain > 0 {
+		logger.Info(fmt.Sprintf("Serving metrics on %s for %v", server.Addr, drain))
+		select {
+		case <-ctx.Done():
+		case <-time.After(drain):
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server.Shutdown(shutdownCtx)
+}
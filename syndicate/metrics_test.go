@@ -0,0 +1,136 @@
+package syndicate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics_ScrapeAfterRun(t *testing.T) {
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if strings.Contains(r.PostForm.Get("status"), "Broken Article") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	originalMetrics := metrics
+	metrics = newRunMetrics()
+	defer func() { metrics = originalMetrics }()
+
+	server, err := startMetricsServer("127.0.0.1:0", metrics)
+	if err != nil {
+		t.Fatalf("startMetricsServer failed: %v", err)
+	}
+	defer server.Close()
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Output: outputMastodon, Concurrency: 1}
+	fetcher := &FakeSource{Items: []*PocketItem{
+		{Title: "Test Article 1", URL: "https://example.com/article1", IsArticle: true},
+		{Title: "Broken Article", URL: "https://example.com/broken", IsArticle: true},
+		{Title: "Test Article 2", URL: "https://example.com/article2", IsArticle: true},
+	}}
+	run(context.Background(), config, fetcher, newTestPoster(t, config), nil, nil)
+
+	resp, err := http.Get("http://" + server.Addr + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	for _, want := range []string{
+		"# TYPE pocket2fedi_items_fetched_total counter",
+		"pocket2fedi_items_fetched_total 3\n",
+		"pocket2fedi_items_posted_total 2\n",
+		"pocket2fedi_post_failures_total 1\n",
+		"# TYPE pocket2fedi_mastodon_request_duration_seconds histogram",
+		"pocket2fedi_mastodon_request_duration_seconds_bucket{le=\"+Inf\"} 3\n",
+		"pocket2fedi_mastodon_request_duration_seconds_count 3\n",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected the metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestRateLimitTransport_TimesOnlyMastodonPosts(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+
+	originalMetrics := metrics
+	metrics = newRunMetrics()
+	defer func() { metrics = originalMetrics }()
+
+	client := &http.Client{Transport: &rateLimitTransport{}}
+	for _, request := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/statuses"},
+		{http.MethodGet, "/api/v2/instance"},
+		{http.MethodPost, "/api/notes/create"},
+		{http.MethodPost, "/xrpc/com.atproto.repo.createRecord"},
+	} {
+		req, _ := http.NewRequest(request.method, mockServer.URL+request.path, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", request.method, request.path, err)
+		}
+		resp.Body.Close()
+	}
+
+	if metrics.latencyCount != 1 {
+		t.Errorf("Expected only the Mastodon status post to be timed, got %d observations", metrics.latencyCount)
+	}
+}
+
+func TestRunMetrics_LatencyBuckets(t *testing.T) {
+	m := newRunMetrics()
+	m.observeRequest(30 * time.Millisecond)
+	m.observeRequest(300 * time.Millisecond)
+	m.observeRequest(time.Minute)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Buckets are cumulative, and only +Inf holds the minute-long request
+	for _, want := range []string{
+		`pocket2fedi_mastodon_request_duration_seconds_bucket{le="0.05"} 1`,
+		`pocket2fedi_mastodon_request_duration_seconds_bucket{le="0.25"} 1`,
+		`pocket2fedi_mastodon_request_duration_seconds_bucket{le="0.5"} 2`,
+		`pocket2fedi_mastodon_request_duration_seconds_bucket{le="10"} 2`,
+		`pocket2fedi_mastodon_request_duration_seconds_bucket{le="+Inf"} 3`,
+		`pocket2fedi_mastodon_request_duration_seconds_sum 60.33`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected the metrics to contain %q, got:\n%s", want, rec.Body.String())
+		}
+	}
+}
+
+func TestDrainMetrics_StopsOnCancel(t *testing.T) {
+	server, err := startMetricsServer("127.0.0.1:0", newRunMetrics())
+	if err != nil {
+		t.Fatalf("startMetricsServer failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	DrainMetrics(ctx, server, time.Hour)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected a cancelled drain to return promptly, took %v", elapsed)
+	}
+
+	if _, err := http.Get("http://" + server.Addr + "/metrics"); err == nil {
+		t.Errorf("Expected the metrics server to be shut down")
+	}
+}
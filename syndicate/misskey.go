@@ -0,0 +1,111 @@
+package syndicate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mattn/go-mastodon"
+)
+
+// MisskeyPoster posts notes to a Misskey server
+type MisskeyPoster struct {
+	server      string
+	accessToken string
+	visibility  string
+	thread      bool
+	lastID      string
+}
+
+// misskeyNote is the request body for /api/notes/create
+type misskeyNote struct {
+	Token      string `json:"i"`
+	Text       string `json:"text"`
+	Visibility string `json:"visibility,omitempty"`
+	CW         string `json:"cw,omitempty"`
+	ReplyID    string `json:"replyId,omitempty"`
+}
+
+// misskeyCreatedNote is the part of the /api/notes/create response we use
+type misskeyCreatedNote struct {
+	CreatedNote struct {
+		ID string `json:"id"`
+	} `json:"createdNote"`
+}
+
+// misskeyError is the error body Misskey returns for a failed request
+type misskeyError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// misskeyVisibility maps a Mastodon visibility to the Misskey equivalent.
+// Direct notes go only to the poster, since no recipients are given.
+func misskeyVisibility(visibility string) string {
+	switch visibility {
+	case mastodon.VisibilityUnlisted:
+		return "home"
+	case mastodon.VisibilityFollowersOnly:
+		return "followers"
+	case mastodon.VisibilityDirectMessage:
+		return "specified"
+	default:
+		return "public"
+	}
+}
+
+// Post posts status as a note without a content warning
+func (p *MisskeyPoster) Post(ctx context.Context, status string) (string, error) {
+	return p.PostWithOptions(ctx, status, statusOptions{})
+}
+
+// PostWithOptions posts status as a note behind the content warning in opts,
+// if set. Misskey notes have no language, and images aren't attached.
+func (p *MisskeyPoster) PostWithOptions(ctx context.Context, status string, opts statusOptions) (string, error) {
+	body, err := json.Marshal(misskeyNote{
+		Token:      p.accessToken,
+		Text:       status,
+		Visibility: p.visibility,
+		CW:         opts.spoiler,
+		ReplyID:    p.lastID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode Misskey note: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(p.server, "/") + "/api/notes/create"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create Misskey request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: httpTimeout, Transport: mastodonRateLimit}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to post to Misskey: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr misskeyError
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error.Message != "" {
+			return "", fmt.Errorf("failed to post to Misskey: %s: %s (%s)", resp.Status, apiErr.Error.Message, apiErr.Error.Code)
+		}
+		return "", fmt.Errorf("failed to post to Misskey: %s", resp.Status)
+	}
+
+	var created misskeyCreatedNote
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("failed to decode Misskey response: %w", err)
+	}
+	if p.thread {
+		p.lastID = created.CreatedNote.ID
+	}
+	return strings.TrimSuffix(p.server, "/") + "/notes/" + created.CreatedNote.ID, nil
+}
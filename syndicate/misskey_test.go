@@ -0,0 +1,117 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-mastodon"
+)
+
+func TestMisskeyPoster_Post(t *testing.T) {
+	var notes []misskeyNote
+	mockMisskeyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/notes/create" {
+			t.Errorf("Expected POST /api/notes/create, got %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON request, got Content-Type '%s'", r.Header.Get("Content-Type"))
+		}
+		var note misskeyNote
+		json.NewDecoder(r.Body).Decode(&note)
+		notes = append(notes, note)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"createdNote": {"id": "note%d", "text": %q}}`, len(notes), note.Text)
+	}))
+	defer mockMisskeyServer.Close()
+
+	config := &Config{FediverseType: fediverseMisskey, MastodonServer: mockMisskeyServer.URL + "/", MastodonToken: "test_misskey_token", Visibility: mastodon.VisibilityPublic, ThreadMode: true}
+	poster, err := NewPoster(config)
+	if err != nil {
+		t.Fatalf("NewPoster failed: %v", err)
+	}
+
+	statusURL, err := postStatus(context.Background(), poster, "First note", statusOptions{})
+	if err != nil {
+		t.Fatalf("postStatus failed: %v", err)
+	}
+	if statusURL != mockMisskeyServer.URL+"/notes/note1" {
+		t.Errorf("Expected the note's URL, got '%s'", statusURL)
+	}
+	if _, err := postStatus(context.Background(), poster, "Second note", statusOptions{spoiler: "politics"}); err != nil {
+		t.Fatalf("postStatus failed: %v", err)
+	}
+
+	expected := []misskeyNote{
+		{Token: "test_misskey_token", Text: "First note", Visibility: "public"},
+		{Token: "test_misskey_token", Text: "Second note", Visibility: "public", CW: "politics", ReplyID: "note1"},
+	}
+	if len(notes) != len(expected) {
+		t.Fatalf("Expected %d notes, got %d", len(expected), len(notes))
+	}
+	for i := range expected {
+		if notes[i] != expected[i] {
+			t.Errorf("Note %d: expected %+v, got %+v", i+1, expected[i], notes[i])
+		}
+	}
+}
+
+func TestMisskeyPoster_Failure(t *testing.T) {
+	mockMisskeyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": {"message": "Authentication failed.", "code": "AUTHENTICATION_FAILED", "id": "b0a7f5f8"}}`))
+	}))
+	defer mockMisskeyServer.Close()
+
+	poster := &MisskeyPoster{server: mockMisskeyServer.URL, accessToken: "bad_token"}
+	_, err := poster.Post(context.Background(), "Test note")
+	if err == nil || !strings.Contains(err.Error(), "AUTHENTICATION_FAILED") {
+		t.Errorf("Expected an authentication error, got %v", err)
+	}
+}
+
+func TestMisskeyVisibility(t *testing.T) {
+	tests := map[string]string{
+		mastodon.VisibilityPublic:        "public",
+		mastodon.VisibilityUnlisted:      "home",
+		mastodon.VisibilityFollowersOnly: "followers",
+		mastodon.VisibilityDirectMessage: "specified",
+		"":                               "public",
+	}
+
+	for visibility, expected := range tests {
+		if got := misskeyVisibility(visibility); got != expected {
+			t.Errorf("misskeyVisibility(%q): expected '%s', got '%s'", visibility, expected, got)
+		}
+	}
+}
+
+func TestPostSaves_Misskey(t *testing.T) {
+	var texts []string
+	mockMisskeyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var note misskeyNote
+		json.NewDecoder(r.Body).Decode(&note)
+		texts = append(texts, note.Text)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"createdNote": {"id": "note1"}}`))
+	}))
+	defer mockMisskeyServer.Close()
+
+	config := &Config{FediverseType: fediverseMisskey, MastodonServer: mockMisskeyServer.URL, MastodonToken: "test_misskey_token", Output: outputMastodon}
+	posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, []*PocketItem{
+		{Title: "Test Article", URL: "https://example.com/article"},
+	})
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+	if posted != 1 || len(errs) != 0 {
+		t.Errorf("Expected 1 posted and 0 failed, got %d posted and %d failed", posted, len(errs))
+	}
+	if len(texts) != 1 || texts[0] != "New Pocket save: Test Article - https://example.com/article" {
+		t.Errorf("Expected the rendered status as the note text, got %q", texts)
+	}
+}
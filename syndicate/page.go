@@ -0,0 +1,142 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// pageHead holds the metadata we read from an article's <head>
+type pageHead struct {
+	Canonical     string
+	OGTitle       string
+	OGDescription string
+}
+
+// pageHeadCache fetches page metadata through the enrichment limiter,
+// remembering each URL's result for the rest of the run
+type pageHeadCache struct {
+	limiter *fetchLimiter
+
+	mu    sync.Mutex
+	heads map[string]*pageHead
+}
+
+// newPageHeadCache creates an empty cache whose fetches are paced by limiter
+func newPageHeadCache(limiter *fetchLimiter) *pageHeadCache {
+	return &pageHeadCache{limiter: limiter, heads: make(map[string]*pageHead)}
+}
+
+// get returns the metadata for pageURL, fetching it on first use
+func (c *pageHeadCache) get(ctx context.Context, pageURL string) (*pageHead, error) {
+	c.mu.Lock()
+	head, ok := c.heads[pageURL]
+	c.mu.Unlock()
+	if ok {
+		return head, nil
+	}
+
+	head, err := fetchPageHead(ctx, c.limiter, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.heads[pageURL] = head
+	c.mu.Unlock()
+	return head, nil
+}
+
+// fetchPageHead fetches a page and reads its canonical link and Open Graph
+// title and description. Missing tags are left empty.
+func fetchPageHead(ctx context.Context, limiter *fetchLimiter, pageURL string) (*pageHead, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	release, err := limiter.acquire(ctx, u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("gave up waiting to fetch page: %w", err)
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create page request: %w", err)
+	}
+
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("page returned status %d", resp.StatusCode)
+	}
+
+	head := &pageHead{}
+	tokenizer := html.NewTokenizer(resp.Body)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return head, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			attrs := make(map[string]string)
+			for _, attr := range token.Attr {
+				attrs[attr.Key] = attr.Val
+			}
+
+			switch token.Data {
+			case "body":
+				return head, nil
+			case "link":
+				if strings.EqualFold(attrs["rel"], "canonical") && attrs["href"] != "" && head.Canonical == "" {
+					canonical, err := u.Parse(attrs["href"])
+					if err != nil {
+						return nil, fmt.Errorf("invalid canonical link %q: %w", attrs["href"], err)
+					}
+					head.Canonical = canonical.String()
+				}
+			case "meta":
+				switch attrs["property"] {
+				case "og:title":
+					head.OGTitle = strings.TrimSpace(attrs["content"])
+				case "og:description":
+					head.OGDescription = strings.TrimSpace(attrs["content"])
+				}
+			}
+		}
+	}
+}
+
+// fillMissingTitles gives saves without a title the page's og:title, or the
+// URL's host name when the page has none. A title that is only the host name,
+// as bestTitle gives Pocket saves with no title, counts as missing.
+func fillMissingTitles(ctx context.Context, pages *pageHeadCache, saves []*PocketItem) {
+	for _, save := range saves {
+		host := urlHost(save.URL)
+		if save.Title != "" && save.Title != host {
+			continue
+		}
+
+		head, err := pages.get(ctx, save.URL)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error fetching page metadata for '%s': %v", save.URL, err), itemAttrs(save, "error", err)...)
+		} else if head.OGTitle != "" {
+			save.Title = head.OGTitle
+			continue
+		}
+
+		if host != "" {
+			save.Title = host
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchPageHead(t *testing.T) {
+	mockPage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<!DOCTYPE html>
+<html><head>
+<title>Ignored</title>
+<meta property="og:title" content=" Open Graph Title ">
+<meta property="og:description" content="A description of the article.">
+<link rel="canonical" href="https://example.com/canonical">
+</head><body><meta property="og:title" content="Too late"></body></html>`))
+	}))
+	defer mockPage.Close()
+
+	head, err := fetchPageHead(context.Background(), nil, mockPage.URL)
+	if err != nil {
+		t.Fatalf("fetchPageHead failed: %v", err)
+	}
+
+	expected := pageHead{
+		Canonical:     "https://example.com/canonical",
+		OGTitle:       "Open Graph Title",
+		OGDescription: "A description of the article.",
+	}
+	if *head != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *head)
+	}
+}
+
+func TestFillMissingTitles(t *testing.T) {
+	requests := 0
+	mockPage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if strings.HasPrefix(r.URL.Path, "/bare") {
+			w.Write([]byte(`<html><head><title>No OG</title></head></html>`))
+			return
+		}
+		w.Write([]byte(`<html><head><meta property="og:title" content="Fetched Title"></head></html>`))
+	}))
+	defer mockPage.Close()
+
+	saves := []*PocketItem{
+		{Title: "", URL: mockPage.URL + "/article"},
+		{Title: "", URL: mockPage.URL + "/article"},
+		{Title: "", URL: mockPage.URL + "/bare"},
+		{Title: "Pocket Title", URL: mockPage.URL + "/titled"},
+		{Title: "127.0.0.1", URL: mockPage.URL + "/host-only"},
+	}
+
+	fillMissingTitles(context.Background(), newPageHeadCache(nil), saves)
+
+	if saves[0].Title != "Fetched Title" || saves[1].Title != "Fetched Title" {
+		t.Errorf("Expected og:title to fill empty titles, got '%s' and '%s'", saves[0].Title, saves[1].Title)
+	}
+	if saves[2].Title != "127.0.0.1" {
+		t.Errorf("Expected host name fallback '127.0.0.1', got '%s'", saves[2].Title)
+	}
+	if saves[3].Title != "Pocket Title" {
+		t.Errorf("Expected Pocket's title to be kept, got '%s'", saves[3].Title)
+	}
+	if saves[4].Title != "Fetched Title" {
+		t.Errorf("Expected a host name title to be replaced by og:title, got '%s'", saves[4].Title)
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 page fetches with per-URL caching, got %d", requests)
+	}
+}
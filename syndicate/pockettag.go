@@ -0,0 +1,63 @@
+package syndicate
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/motemen/go-pocket/api"
+)
+
+// pocketTagAction is a tags_add action for Pocket's modify API. The library's
+// api.Action only knows archiving.
+type pocketTagAction struct {
+	Action string `json:"action"`
+	ItemID string `json:"item_id"`
+	Tags   string `json:"tags"`
+}
+
+// pocketModifyRequest is the body of a request to Pocket's modify API
+type pocketModifyRequest struct {
+	ConsumerKey string            `json:"consumer_key"`
+	AccessToken string            `json:"access_token"`
+	Actions     []pocketTagAction `json:"actions"`
+}
+
+// pocketModifyResult is Pocket's reply to a modify request. The library's
+// api.ModifyResult can't decode action_results.
+type pocketModifyResult struct {
+	ActionResults []bool `json:"action_results"`
+	Status        int    `json:"status"`
+}
+
+// tagPocketItem adds tag to the Pocket item itemID
+func tagPocketItem(consumerKey, accessToken, itemID, tag string) error {
+	request := pocketModifyRequest{
+		ConsumerKey: consumerKey,
+		AccessToken: accessToken,
+		Actions:     []pocketTagAction{{Action: "tags_add", ItemID: itemID, Tags: tag}},
+	}
+	var result pocketModifyResult
+	if err := api.PostJSON("/v3/send", request, &result); err != nil {
+		return fmt.Errorf("failed to tag Pocket item %s: %w", itemID, err)
+	}
+	if len(result.ActionResults) != 1 || !result.ActionResults[0] {
+		return fmt.Errorf("failed to tag Pocket item %s: Pocket rejected the change", itemID)
+	}
+	return nil
+}
+
+// tagPosted tags save in Pocket with config.TagAfterPost, if set, so later
+// runs skip it even without a state store
+func tagPosted(config *Config, save *PocketItem) {
+	if config.TagAfterPost == "" || save.ItemID == "" {
+		return
+	}
+	if err := tagPocketItem(config.PocketConsumerKey, config.PocketAccessToken, save.ItemID, config.TagAfterPost); err != nil {
+		logger.Error(fmt.Sprintf("Error tagging '%s' as posted, it may be posted again: %v", save.Title, err), itemAttrs(save, "error", err)...)
+	}
+}
+
+// hasTag reports whether item carries tag
+func (item *PocketItem) hasTag(tag string) bool {
+	return slices.Contains(item.Tags, tag)
+}
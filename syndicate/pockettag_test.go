@@ -0,0 +1,107 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/motemen/go-pocket/api"
+)
+
+func TestTagPocketItem(t *testing.T) {
+	var request pocketModifyRequest
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/send" {
+			t.Errorf("Expected a request to /v3/send, got '%s'", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode modify request: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"action_results": [true], "status": 1}`))
+	}))
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	if err := tagPocketItem("test_consumer_key", "test_access_token", "123", "posted-to-fedi"); err != nil {
+		t.Fatalf("tagPocketItem failed: %v", err)
+	}
+
+	if request.ConsumerKey != "test_consumer_key" || request.AccessToken != "test_access_token" {
+		t.Errorf("Expected the Pocket credentials, got %+v", request)
+	}
+	expected := pocketTagAction{Action: "tags_add", ItemID: "123", Tags: "posted-to-fedi"}
+	if len(request.Actions) != 1 || request.Actions[0] != expected {
+		t.Errorf("Expected actions [%+v], got %+v", expected, request.Actions)
+	}
+}
+
+func TestTagPocketItem_Rejected(t *testing.T) {
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"action_results": [false], "status": 1}`))
+	}))
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	if err := tagPocketItem("test_consumer_key", "test_access_token", "123", "posted-to-fedi"); err == nil {
+		t.Errorf("Expected an error when Pocket rejects the action")
+	}
+}
+
+func TestFilterSaves_TagAfterPost(t *testing.T) {
+	saves := []*PocketItem{
+		{ItemID: "1", Title: "Article 1", URL: "https://example.com/1", Tags: []string{"golang"}},
+		{ItemID: "2", Title: "Article 2", URL: "https://example.com/2", Tags: []string{"golang", "posted-to-fedi"}},
+		{ItemID: "3", Title: "Article 3", URL: "https://example.com/3"},
+	}
+
+	filtered := filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost, TagAfterPost: "posted-to-fedi"})
+	if len(filtered) != 2 || filtered[0].ItemID != "1" || filtered[1].ItemID != "3" {
+		t.Errorf("Expected saves 1 and 3, got %+v", filtered)
+	}
+
+	if filtered := filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost}); len(filtered) != 3 {
+		t.Errorf("Expected every save without POCKET_TAG_AFTER_POST, got %d", len(filtered))
+	}
+}
+
+func TestRun_TagAfterPost(t *testing.T) {
+	var tagged []string
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request pocketModifyRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		for _, action := range request.Actions {
+			tagged = append(tagged, action.ItemID)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"action_results": [true], "status": 1}`))
+	}))
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	config := &Config{FediverseType: fediverseMisskey, Output: outputMastodon, ImageItemPolicy: imageItemsPost, StatusTemplate: defaultStatusTemplate, TagAfterPost: "posted-to-fedi"}
+	source := &FakeSource{Items: []*PocketItem{
+		{ItemID: "1", Title: "New Article", URL: "https://example.com/1", IsArticle: true},
+		{ItemID: "2", Title: "Old Article", URL: "https://example.com/2", IsArticle: true, Tags: []string{"posted-to-fedi"}},
+	}}
+	poster := &FakePoster{}
+	if result := run(context.Background(), config, source, poster, nil, nil); result.Posted != 1 {
+		t.Fatalf("Expected 1 post, got %+v", result)
+	}
+
+	if len(tagged) != 1 || tagged[0] != "1" {
+		t.Errorf("Expected only item 1 to be tagged, got %v", tagged)
+	}
+}
@@ -0,0 +1,152 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mattn/go-mastodon"
+)
+
+// Supported fediverse server types
+const (
+	fediverseMastodon = "mastodon"
+	fediverseMisskey  = "misskey"
+	fediverseBluesky  = "bluesky"
+)
+
+// Poster publishes statuses to a fediverse account and returns the web URL
+// of each new status. Each implementation applies the configured visibility
+// where the server has one and, in thread mode, posts every status after the
+// first as a reply to the previous one.
+type Poster interface {
+	Post(ctx context.Context, status string) (statusURL string, err error)
+}
+
+// statusOptions are per-status settings beyond the text. Empty fields are
+// left to the server's defaults.
+type statusOptions struct {
+	spoiler  string // content warning
+	language string // ISO 639-1 code
+	imageURL string // image to attach
+	poll     *mastodon.TootPoll
+}
+
+// optionsPoster is a Poster that supports some statusOptions. Options the
+// server has no equivalent for are ignored.
+type optionsPoster interface {
+	Poster
+	PostWithOptions(ctx context.Context, status string, opts statusOptions) (statusURL string, err error)
+}
+
+// postStatus posts status with poster, applying opts if the poster supports
+// them, and returns the new status's URL
+func postStatus(ctx context.Context, poster Poster, status string, opts statusOptions) (string, error) {
+	if op, ok := poster.(optionsPoster); ok {
+		return op.PostWithOptions(ctx, status, opts)
+	}
+	return poster.Post(ctx, status)
+}
+
+// MastodonPoster posts to Mastodon and servers with a Mastodon-compatible
+// API such as Pleroma and Akkoma
+type MastodonPoster struct {
+	server      string
+	accessToken string
+	visibility  string
+	thread      bool
+	lastID      mastodon.ID
+}
+
+// Post posts status without a content warning, image or poll
+func (p *MastodonPoster) Post(ctx context.Context, status string) (string, error) {
+	return p.PostWithOptions(ctx, status, statusOptions{})
+}
+
+// PostWithOptions posts status behind the content warning, tagged with the
+// language and with the image or poll attached in opts, if set. If the image can't
+// be attached the status is posted without it.
+func (p *MastodonPoster) PostWithOptions(ctx context.Context, status string, opts statusOptions) (string, error) {
+	var mediaIDs []mastodon.ID
+	if opts.imageURL != "" {
+		mediaID, err := uploadImage(ctx, p.server, p.accessToken, opts.imageURL)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Posting without the image %s: %v", opts.imageURL, err), "error", err)
+		} else {
+			mediaIDs = []mastodon.ID{mediaID}
+		}
+	}
+
+	created, err := postToMastodon(ctx, p.server, p.accessToken, &mastodon.Toot{
+		Status:      status,
+		InReplyToID: p.lastID,
+		MediaIDs:    mediaIDs,
+		Visibility:  p.visibility,
+		SpoilerText: opts.spoiler,
+		Poll:        opts.poll,
+		Language:    opts.language,
+	})
+	if err != nil {
+		return "", err
+	}
+	if p.thread {
+		p.lastID = created.ID
+	}
+	return created.URL, nil
+}
+
+// NewPoster returns the Poster for the configured server type, posting to
+// each of the config's Targets as well if there are any
+func NewPoster(config *Config) (Poster, error) {
+	poster, err := newAccountPoster(config)
+	if err != nil || len(config.Targets) == 0 {
+		return poster, err
+	}
+
+	name := config.MastodonServer
+	if config.FediverseType == fediverseBluesky {
+		name = config.BlueskyPDS
+	}
+	fanOut := &fanOutPoster{names: []string{name}, posters: []Poster{poster}}
+	for _, target := range config.Targets {
+		targetConfig := *config
+		targetConfig.FediverseType = target.Type
+		targetConfig.MastodonServer = target.Server
+		targetConfig.MastodonToken = target.Token
+		poster, err := newAccountPoster(&targetConfig)
+		if err != nil {
+			return nil, err
+		}
+		fanOut.names = append(fanOut.names, target.Server)
+		fanOut.posters = append(fanOut.posters, poster)
+	}
+	return fanOut, nil
+}
+
+// newAccountPoster returns the Poster for the single account in config
+func newAccountPoster(config *Config) (Poster, error) {
+	switch config.FediverseType {
+	case "", fediverseMastodon:
+		return &MastodonPoster{
+			server:      config.MastodonServer,
+			accessToken: config.MastodonToken,
+			visibility:  config.Visibility,
+			thread:      config.ThreadMode,
+		}, nil
+	case fediverseMisskey:
+		return &MisskeyPoster{
+			server:      config.MastodonServer,
+			accessToken: config.MastodonToken,
+			visibility:  misskeyVisibility(config.Visibility),
+			thread:      config.ThreadMode,
+		}, nil
+	case fediverseBluesky:
+		return &BlueskyPoster{
+			server:      config.BlueskyPDS,
+			handle:      config.BlueskyHandle,
+			appPassword: config.BlueskyAppPassword,
+			thread:      config.ThreadMode,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown fediverse type %q", config.FediverseType)
+	}
+}
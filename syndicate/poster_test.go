@@ -0,0 +1,91 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattn/go-mastodon"
+)
+
+func TestNewPoster(t *testing.T) {
+	tests := []struct {
+		fediverseType string
+		expected      string
+	}{
+		{"", "*syndicate.MastodonPoster"},
+		{fediverseMastodon, "*syndicate.MastodonPoster"},
+		{fediverseMisskey, "*syndicate.MisskeyPoster"},
+	}
+
+	for _, tt := range tests {
+		poster, err := NewPoster(&Config{FediverseType: tt.fediverseType})
+		if err != nil {
+			t.Fatalf("NewPoster(%q) failed: %v", tt.fediverseType, err)
+		}
+		if got := fmt.Sprintf("%T", poster); got != tt.expected {
+			t.Errorf("FEDIVERSE_TYPE=%q: expected %s, got %s", tt.fediverseType, tt.expected, got)
+		}
+	}
+
+	if _, err := NewPoster(&Config{FediverseType: "friendica"}); err == nil {
+		t.Errorf("Expected an error for an unknown fediverse type")
+	}
+}
+
+func TestMastodonPoster_Post(t *testing.T) {
+	type post struct {
+		status, visibility, spoiler, inReplyTo string
+	}
+	var posts []post
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		posts = append(posts, post{r.PostForm.Get("status"), r.PostForm.Get("visibility"), r.PostForm.Get("spoiler_text"), r.PostForm.Get("in_reply_to_id")})
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"id": "%d", "url": "https://mastodon.example/@test/%d"}`, len(posts), len(posts))
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Visibility: mastodon.VisibilityUnlisted, ThreadMode: true}
+	poster, err := NewPoster(config)
+	if err != nil {
+		t.Fatalf("NewPoster failed: %v", err)
+	}
+
+	statusURL, err := postStatus(context.Background(), poster, "First post", statusOptions{})
+	if err != nil {
+		t.Fatalf("postStatus failed: %v", err)
+	}
+	if statusURL != "https://mastodon.example/@test/1" {
+		t.Errorf("Expected the status URL, got '%s'", statusURL)
+	}
+	if _, err := postStatus(context.Background(), poster, "Second post", statusOptions{spoiler: "politics"}); err != nil {
+		t.Fatalf("postStatus failed: %v", err)
+	}
+
+	expected := []post{
+		{"First post", mastodon.VisibilityUnlisted, "", ""},
+		{"Second post", mastodon.VisibilityUnlisted, "politics", "1"},
+	}
+	if len(posts) != len(expected) {
+		t.Fatalf("Expected %d posts, got %d", len(expected), len(posts))
+	}
+	for i := range expected {
+		if posts[i] != expected[i] {
+			t.Errorf("Post %d: expected %+v, got %+v", i+1, expected[i], posts[i])
+		}
+	}
+}
+
+// newTestPoster returns the Poster for config, failing the test if there
+// isn't one
+func newTestPoster(t *testing.T, config *Config) Poster {
+	t.Helper()
+	poster, err := NewPoster(config)
+	if err != nil {
+		t.Fatalf("NewPoster failed: %v", err)
+	}
+	return poster
+}
@@ -0,0 +1,85 @@
+package syndicate
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parseRateLimit reads Mastodon's X-RateLimit-Remaining and X-RateLimit-Reset
+// headers, reporting ok only if both are present and valid
+func parseRateLimit(resp *http.Response) (remaining int, reset time.Time, ok bool) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	reset, err = time.Parse(time.RFC3339, resp.Header.Get("X-RateLimit-Reset"))
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return remaining, reset, true
+}
+
+// rateLimitDelay is how long to wait before the next post. With requests
+// remaining there is no need to wait; once they run out we wait for the
+// reset. Without rate limit headers we fall back to the configured delay.
+func rateLimitDelay(remaining int, reset time.Time, ok bool, now time.Time, fallback time.Duration) time.Duration {
+	if !ok {
+		return fallback
+	}
+	if remaining > 0 {
+		return 0
+	}
+	if wait := reset.Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// rateLimitTransport records the rate limit headers of the last response it
+// carried, since the Mastodon client doesn't expose responses
+type rateLimitTransport struct {
+	mu        sync.Mutex
+	remaining int
+	reset     time.Time
+	ok        bool
+}
+
+// mastodonRateLimit carries every request made to post statuses
+var mastodonRateLimit = &rateLimitTransport{}
+
+// RoundTrip sends the request with httpTransport, adding any
+// Idempotency-Key from its context, and records the rate limit headers of the
+// response and, for Mastodon status posts, how long it took
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := httpTransport.RoundTrip(setIdempotencyKey(req))
+	if isMastodonStatusPost(req) {
+		metrics.observeRequest(time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.remaining, t.reset, t.ok = parseRateLimit(resp)
+	t.mu.Unlock()
+	return resp, nil
+}
+
+// isMastodonStatusPost reports whether req posts a Mastodon status. The
+// transport also carries Misskey, Bluesky, and instance API requests, which
+// the request latency histogram leaves out.
+func isMastodonStatusPost(req *http.Request) bool {
+	return req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/api/v1/statuses")
+}
+
+// nextDelay returns how long to wait before the next post, based on the last
+// response recorded, or fallback if it had no rate limit headers
+func (t *rateLimitTransport) nextDelay(fallback time.Duration) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return rateLimitDelay(t.remaining, t.reset, t.ok, time.Now(), fallback)
+}
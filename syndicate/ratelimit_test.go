@@ -0,0 +1,120 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-mastodon"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Remaining", "42")
+	resp.Header.Set("X-RateLimit-Reset", "2024-01-01T10:05:00.000Z")
+
+	remaining, reset, ok := parseRateLimit(resp)
+	if !ok {
+		t.Fatalf("parseRateLimit should have found the headers")
+	}
+	if remaining != 42 {
+		t.Errorf("Expected 42 remaining, got %d", remaining)
+	}
+	if expected := time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC); !reset.Equal(expected) {
+		t.Errorf("Expected reset at %v, got %v", expected, reset)
+	}
+
+	if _, _, ok := parseRateLimit(&http.Response{Header: http.Header{}}); ok {
+		t.Errorf("parseRateLimit should report missing headers")
+	}
+}
+
+func TestRateLimitDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		remaining int
+		reset     time.Time
+		ok        bool
+		expected  time.Duration
+	}{
+		{"no headers", 0, time.Time{}, false, 5 * time.Second},
+		{"requests remaining", 5, now.Add(time.Minute), true, 0},
+		{"exhausted", 0, now.Add(90 * time.Second), true, 90 * time.Second},
+		{"reset already passed", 0, now.Add(-time.Second), true, 0},
+	}
+
+	for _, tt := range tests {
+		if delay := rateLimitDelay(tt.remaining, tt.reset, tt.ok, now, 5*time.Second); delay != tt.expected {
+			t.Errorf("%s: expected delay %v, got %v", tt.name, tt.expected, delay)
+		}
+	}
+}
+
+func TestPostToMastodon_RecordsRateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", reset.Format(time.RFC3339))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	// Don't leave the exhausted limit behind for other tests
+	defer func() { mastodonRateLimit = &rateLimitTransport{} }()
+
+	_, err := postToMastodon(context.Background(), mockMastodonServer.URL, "test_mastodon_token", &mastodon.Toot{Status: "Test Mastodon post"})
+	if err != nil {
+		t.Fatalf("postToMastodon failed: %v", err)
+	}
+
+	if delay := mastodonRateLimit.nextDelay(defaultPostDelay); delay < 59*time.Minute || delay > time.Hour {
+		t.Errorf("Expected to wait about an hour for the reset, got %v", delay)
+	}
+}
+
+func TestLoadConfigFromEnv_PostDelay(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+	defer func() {
+		os.Unsetenv("POCKET_CONSUMER_KEY")
+		os.Unsetenv("POCKET_ACCESS_TOKEN")
+		os.Unsetenv("MASTODON_SERVER")
+		os.Unsetenv("MASTODON_TOKEN")
+		os.Unsetenv("POST_DELAY")
+	}()
+
+	config, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("LoadConfigFromEnv failed: %v", err)
+	}
+	if config.PostDelay != defaultPostDelay || config.Sources["PostDelay"] != "default" {
+		t.Errorf("Expected the default PostDelay of 2s, got %v from %q", config.PostDelay, config.Sources["PostDelay"])
+	}
+
+	for value, expected := range map[string]time.Duration{"500ms": 500 * time.Millisecond, "5s": 5 * time.Second, "0": 0} {
+		os.Setenv("POST_DELAY", value)
+		config, err := LoadConfigFromEnv()
+		if err != nil {
+			t.Fatalf("LoadConfigFromEnv with POST_DELAY=%s failed: %v", value, err)
+		}
+		if config.PostDelay != expected {
+			t.Errorf("POST_DELAY=%s: expected %v, got %v", value, expected, config.PostDelay)
+		}
+	}
+
+	for _, value := range []string{"soon", "5", "-1s"} {
+		os.Setenv("POST_DELAY", value)
+		if _, err := LoadConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "POST_DELAY") {
+			t.Errorf("POST_DELAY=%s: expected an error mentioning POST_DELAY, got %v", value, err)
+		}
+	}
+}
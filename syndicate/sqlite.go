@@ -0,0 +1,101 @@
+package syndicate
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the state tables. Each posted item is kept with when
+// it was posted and, when known, the URL of the status, so the history can be
+// queried with any SQLite client.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS posted_items (
+	item_id    TEXT PRIMARY KEY,
+	posted_at  TEXT NOT NULL,
+	status_url TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS sync_state (
+	id        INTEGER PRIMARY KEY CHECK (id = 1),
+	last_sync INTEGER NOT NULL
+);`
+
+// SQLiteStateStore is a StateStore kept in a SQLite database. Unlike the JSON
+// state file it is updated a row at a time and can be shared by several
+// processes.
+type SQLiteStateStore struct {
+	db *sql.DB
+}
+
+// openSQLiteStateStore opens the database at path, creating it and its
+// tables if needed
+func openSQLiteStateStore(path string) (*SQLiteStateStore, error) {
+	// Wait for other writers instead of failing with "database is locked"
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state database: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create state tables in %s: %w", path, err)
+	}
+	return &SQLiteStateStore{db: db}, nil
+}
+
+// Posted reports whether itemID has been posted. A failed lookup is logged
+// and treated as not posted.
+func (s *SQLiteStateStore) Posted(itemID string) bool {
+	var found int
+	err := s.db.QueryRow(`SELECT 1 FROM posted_items WHERE item_id = ?`, itemID).Scan(&found)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error(fmt.Sprintf("Error looking up item %s in the state database: %v", itemID, err), "item_id", itemID, "error", err)
+	}
+	return err == nil
+}
+
+// MarkPosted records itemID as posted now
+func (s *SQLiteStateStore) MarkPosted(itemID string) error {
+	return s.MarkPostedAs(itemID, "")
+}
+
+// MarkPostedAs records itemID as posted now as the status at statusURL
+func (s *SQLiteStateStore) MarkPostedAs(itemID, statusURL string) error {
+	_, err := s.db.Exec(`INSERT INTO posted_items (item_id, posted_at, status_url) VALUES (?, ?, ?)
+		ON CONFLICT (item_id) DO UPDATE SET posted_at = excluded.posted_at, status_url = excluded.status_url`,
+		itemID, time.Now().UTC().Format(time.RFC3339), statusURL)
+	if err != nil {
+		return fmt.Errorf("failed to record posted item: %w", err)
+	}
+	return nil
+}
+
+// LastSync returns the time recorded by SetLastSync. A failed lookup is
+// logged and treated as no sync yet.
+func (s *SQLiteStateStore) LastSync() time.Time {
+	var lastSync int64
+	err := s.db.QueryRow(`SELECT last_sync FROM sync_state WHERE id = 1`).Scan(&lastSync)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.Error(fmt.Sprintf("Error reading last sync time from the state database: %v", err), "error", err)
+		}
+		return time.Time{}
+	}
+	return time.Unix(lastSync, 0)
+}
+
+// SetLastSync records t as the last sync time
+func (s *SQLiteStateStore) SetLastSync(t time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO sync_state (id, last_sync) VALUES (1, ?)
+		ON CONFLICT (id) DO UPDATE SET last_sync = excluded.last_sync`, t.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record last sync time: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *SQLiteStateStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,104 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStateStore_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+
+	store, err := openSQLiteStateStore(path)
+	if err != nil {
+		t.Fatalf("openSQLiteStateStore failed on a new database: %v", err)
+	}
+	if store.Posted("123") {
+		t.Errorf("Expected an empty store")
+	}
+	if !store.LastSync().IsZero() {
+		t.Errorf("Expected no last sync time, got %v", store.LastSync())
+	}
+	if err := store.MarkPosted("123"); err != nil {
+		t.Fatalf("MarkPosted failed: %v", err)
+	}
+	if err := store.MarkPostedAs("456", "https://mastodon.example/@test/1"); err != nil {
+		t.Fatalf("MarkPostedAs failed: %v", err)
+	}
+	lastSync := time.Unix(1704067200, 0)
+	if err := store.SetLastSync(lastSync); err != nil {
+		t.Fatalf("SetLastSync failed: %v", err)
+	}
+	if !store.Posted("123") || !store.Posted("456") {
+		t.Errorf("Expected items 123 and 456 to be posted")
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := openSQLiteStateStore(path)
+	if err != nil {
+		t.Fatalf("openSQLiteStateStore failed: %v", err)
+	}
+	defer reopened.Close()
+	if !reopened.Posted("123") || !reopened.Posted("456") {
+		t.Errorf("Expected items 123 and 456 to be remembered after reopening")
+	}
+	if reopened.Posted("789") {
+		t.Errorf("Expected item 789 not to be posted")
+	}
+	if !reopened.LastSync().Equal(lastSync) {
+		t.Errorf("Expected last sync %v, got %v", lastSync, reopened.LastSync())
+	}
+
+	var postedAt, statusURL string
+	err = reopened.db.QueryRow(`SELECT posted_at, status_url FROM posted_items WHERE item_id = ?`, "456").Scan(&postedAt, &statusURL)
+	if err != nil {
+		t.Fatalf("Failed to query posted item: %v", err)
+	}
+	if statusURL != "https://mastodon.example/@test/1" {
+		t.Errorf("Expected the status URL to be recorded, got '%s'", statusURL)
+	}
+	if _, err := time.Parse(time.RFC3339, postedAt); err != nil {
+		t.Errorf("Expected an RFC 3339 post time, got '%s'", postedAt)
+	}
+}
+
+func TestPostSaves_SQLiteStateStore(t *testing.T) {
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1", "url": "https://mastodon.example/@test/1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{
+		MastodonServer: mockMastodonServer.URL,
+		MastodonToken:  "test_mastodon_token",
+		Output:         outputMastodon,
+		StateBackend:   stateBackendSQLite,
+		StateDBPath:    filepath.Join(t.TempDir(), "state.db"),
+	}
+	store, err := OpenStateStore(config)
+	if err != nil {
+		t.Fatalf("OpenStateStore failed: %v", err)
+	}
+	defer CloseStateStore(store)
+
+	_, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, store, 0, []*PocketItem{
+		{ItemID: "123", Title: "Test Article", URL: "https://example.com/article"},
+	})
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("postSaves failed: %v %v", err, errs)
+	}
+
+	var statusURL string
+	if err := store.(*SQLiteStateStore).db.QueryRow(`SELECT status_url FROM posted_items WHERE item_id = '123'`).Scan(&statusURL); err != nil {
+		t.Fatalf("Failed to query posted item: %v", err)
+	}
+	if statusURL != "https://mastodon.example/@test/1" {
+		t.Errorf("Expected the posted status URL to be recorded, got '%s'", statusURL)
+	}
+}
@@ -0,0 +1,256 @@
+package syndicate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StateStore remembers which items have already been posted so later runs
+// don't post them again
+type StateStore interface {
+	// Posted reports whether the item was posted by an earlier run
+	Posted(itemID string) bool
+	// MarkPosted records that the item was posted and persists the change
+	MarkPosted(itemID string) error
+	// LastSync is when the newest save posted so far was added, or the zero
+	// time before the first successful run
+	LastSync() time.Time
+	// SetLastSync records a new LastSync and persists the change
+	SetLastSync(t time.Time) error
+}
+
+// statusURLStore is a StateStore that can also keep the URL of the status
+// each item was posted as
+type statusURLStore interface {
+	StateStore
+	// MarkPostedAs records that the item was posted as statusURL
+	MarkPostedAs(itemID, statusURL string) error
+}
+
+// stateFile is the on-disk format of fileStateStore
+type stateFile struct {
+	Posted   []string `json:"posted"`
+	LastSync int64    `json:"last_sync,omitempty"` // Unix seconds
+}
+
+// fileStateStore is a StateStore kept in a JSON file. It is loaded once and
+// rewritten atomically after every change.
+type fileStateStore struct {
+	path     string
+	posted   map[string]bool
+	lastSync time.Time
+}
+
+// loadFileStateStore reads the state file at path. A missing file is an
+// empty store; it is created on the first MarkPosted.
+func loadFileStateStore(path string) (*fileStateStore, error) {
+	store := &fileStateStore{path: path, posted: make(map[string]bool)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state stateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	for _, id := range state.Posted {
+		store.posted[id] = true
+	}
+	if state.LastSync != 0 {
+		store.lastSync = time.Unix(state.LastSync, 0)
+	}
+
+	logger.Info(fmt.Sprintf("Loaded %d posted items from %s", len(store.posted), path), "count", len(store.posted))
+	return store, nil
+}
+
+// Posted reports whether itemID has been posted
+func (s *fileStateStore) Posted(itemID string) bool {
+	return s.posted[itemID]
+}
+
+// MarkPosted records itemID and rewrites the state file
+func (s *fileStateStore) MarkPosted(itemID string) error {
+	s.posted[itemID] = true
+	return s.flush()
+}
+
+// LastSync returns the time recorded by SetLastSync
+func (s *fileStateStore) LastSync() time.Time {
+	return s.lastSync
+}
+
+// SetLastSync records t and rewrites the state file
+func (s *fileStateStore) SetLastSync(t time.Time) error {
+	s.lastSync = t
+	return s.flush()
+}
+
+// flush writes the store to a temporary file and renames it over the state
+// file, so a crash mid-write never leaves a truncated file behind
+func (s *fileStateStore) flush() error {
+	state := stateFile{Posted: make([]string, 0, len(s.posted))}
+	for id := range s.posted {
+		state.Posted = append(state.Posted, id)
+	}
+	sort.Strings(state.Posted)
+	if !s.lastSync.IsZero() {
+		state.LastSync = s.lastSync.Unix()
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write temporary state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// memoryStateStore is a StateStore that only lasts as long as the process,
+// for a daemon running without a state file
+type memoryStateStore struct {
+	mu       sync.Mutex
+	posted   map[string]bool
+	lastSync time.Time
+}
+
+func newMemoryStateStore() *memoryStateStore {
+	return &memoryStateStore{posted: make(map[string]bool)}
+}
+
+// Posted reports whether itemID has been posted
+func (s *memoryStateStore) Posted(itemID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.posted[itemID]
+}
+
+// MarkPosted records itemID
+func (s *memoryStateStore) MarkPosted(itemID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.posted[itemID] = true
+	return nil
+}
+
+// LastSync returns the time recorded by SetLastSync
+func (s *memoryStateStore) LastSync() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSync
+}
+
+// SetLastSync records t
+func (s *memoryStateStore) SetLastSync(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSync = t
+	return nil
+}
+
+// Supported state store backends
+const (
+	stateBackendFile   = "file"
+	stateBackendSQLite = "sqlite"
+)
+
+// OpenStateStore returns the configured StateStore, or nil when no state
+// file is configured and every run posts everything it fetches
+func OpenStateStore(config *Config) (StateStore, error) {
+	if config.StateBackend == stateBackendSQLite {
+		return openSQLiteStateStore(config.StateDBPath)
+	}
+	if config.StateFile == "" {
+		return nil, nil
+	}
+	return loadFileStateStore(config.StateFile)
+}
+
+// CloseStateStore releases the store if it holds an open resource such as a
+// database connection
+func CloseStateStore(store StateStore) {
+	closer, ok := store.(io.Closer)
+	if !ok {
+		return
+	}
+	if err := closer.Close(); err != nil {
+		logger.Error(fmt.Sprintf("Error closing state store: %v", err), "error", err)
+	}
+}
+
+// lastSync returns the store's last sync time, or the zero time without a
+// store so that the full count is fetched
+func lastSync(store StateStore) time.Time {
+	if store == nil {
+		return time.Time{}
+	}
+	return store.LastSync()
+}
+
+// advanceLastSync moves the store's last sync time up to the newest of the
+// posted saves, so the next run only asks for saves added after them
+func advanceLastSync(store StateStore, posted []*PocketItem) {
+	if store == nil {
+		return
+	}
+
+	newest := store.LastSync()
+	for _, save := range posted {
+		if save.TimeAdded.After(newest) {
+			newest = save.TimeAdded
+		}
+	}
+	if newest.Equal(store.LastSync()) {
+		return
+	}
+	if err := store.SetLastSync(newest); err != nil {
+		logger.Error(fmt.Sprintf("Error recording last sync time: %v", err), "error", err)
+	}
+}
+
+// skipPosted drops saves that store says were already posted
+func skipPosted(saves []*PocketItem, store StateStore) []*PocketItem {
+	if store == nil {
+		return saves
+	}
+
+	var fresh []*PocketItem
+	for _, save := range saves {
+		if save.ItemID != "" && store.Posted(save.ItemID) {
+			continue
+		}
+		fresh = append(fresh, save)
+	}
+	if skipped := len(saves) - len(fresh); skipped > 0 {
+		logger.Info(fmt.Sprintf("Skipping %d saves that were already posted", skipped), "count", skipped)
+	}
+	return fresh
+}
@@ -0,0 +1,252 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/motemen/go-pocket/api"
+)
+
+func TestFileStateStore_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := loadFileStateStore(path)
+	if err != nil {
+		t.Fatalf("loadFileStateStore failed on a missing file: %v", err)
+	}
+	if store.Posted("123") {
+		t.Errorf("Expected an empty store")
+	}
+	if err := store.MarkPosted("123"); err != nil {
+		t.Fatalf("MarkPosted failed: %v", err)
+	}
+
+	reloaded, err := loadFileStateStore(path)
+	if err != nil {
+		t.Fatalf("loadFileStateStore failed: %v", err)
+	}
+	if !reloaded.Posted("123") {
+		t.Errorf("Expected item 123 to be remembered after reloading")
+	}
+	if reloaded.Posted("456") {
+		t.Errorf("Expected item 456 not to be posted")
+	}
+
+	// Only the state file itself should be left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the state file, found %d entries", len(entries))
+	}
+}
+
+func TestFileStateStore_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("Failed to write state file: %v", err)
+	}
+
+	if _, err := loadFileStateStore(path); err == nil {
+		t.Errorf("loadFileStateStore should have failed on a corrupt file")
+	}
+}
+
+func TestRun_SecondRunPostsNothing(t *testing.T) {
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"status": 1,
+			"list": {
+				"123": {"resolved_title": "Test Article 1", "resolved_url": "https://example.com/article1", "status": "0"},
+				"456": {"resolved_title": "Test Article 2", "resolved_url": "https://example.com/article2", "status": "0"}
+			}
+		}`))
+	}))
+	defer mockPocketServer.Close()
+
+	var posts int
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	config := &Config{
+		Source:            sourcePocket,
+		PocketConsumerKey: "test_consumer_key",
+		PocketAccessToken: "test_access_token",
+		MastodonServer:    mockMastodonServer.URL,
+		MastodonToken:     "test_mastodon_token",
+		URLSource:         urlSourceResolved,
+		StateFile:         filepath.Join(t.TempDir(), "state.json"),
+	}
+	fetcher, err := NewPocketSource(config)
+	if err != nil {
+		t.Fatalf("NewPocketSource failed: %v", err)
+	}
+
+	for i, expected := range []int{2, 0} {
+		// Each run loads the store afresh, as separate invocations would
+		store, err := OpenStateStore(config)
+		if err != nil {
+			t.Fatalf("OpenStateStore failed: %v", err)
+		}
+
+		posts = 0
+		result := run(context.Background(), config, fetcher, newTestPoster(t, config), nil, store)
+		if result.Err != nil {
+			t.Fatalf("Run %d failed: %v", i+1, result.Err)
+		}
+		if result.Posted != expected || posts != expected {
+			t.Errorf("Run %d: expected %d posts, got %d (%d requests)", i+1, expected, result.Posted, posts)
+		}
+	}
+}
+
+func TestRun_IncrementalSync(t *testing.T) {
+	responses := []string{
+		`{"status": 1, "list": {
+			"123": {"resolved_title": "Test Article 1", "resolved_url": "https://example.com/article1", "status": "0", "time_added": "1704067200"},
+			"456": {"resolved_title": "Test Article 2", "resolved_url": "https://example.com/article2", "status": "0", "time_added": "1704070800"}
+		}}`,
+		`{"status": 1, "list": {
+			"789": {"resolved_title": "Test Article 3", "resolved_url": "https://example.com/article3", "status": "0", "time_added": "1704074400"}
+		}}`,
+	}
+	type request struct {
+		Count int `json:"count"`
+		Since int `json:"since"`
+	}
+	var requests []request
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(responses[len(requests)-1]))
+	}))
+	defer mockPocketServer.Close()
+
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	config := &Config{
+		Source:            sourcePocket,
+		PocketConsumerKey: "test_consumer_key",
+		PocketAccessToken: "test_access_token",
+		Count:             10,
+		MastodonServer:    mockMastodonServer.URL,
+		MastodonToken:     "test_mastodon_token",
+		URLSource:         urlSourceResolved,
+		StateFile:         filepath.Join(t.TempDir(), "state.json"),
+	}
+	fetcher, err := NewPocketSource(config)
+	if err != nil {
+		t.Fatalf("NewPocketSource failed: %v", err)
+	}
+
+	for i, expectedPosts := range []int{2, 1} {
+		store, err := OpenStateStore(config)
+		if err != nil {
+			t.Fatalf("OpenStateStore failed: %v", err)
+		}
+		if result := run(context.Background(), config, fetcher, newTestPoster(t, config), nil, store); result.Posted != expectedPosts {
+			t.Errorf("Run %d: expected %d posts, got %d", i+1, expectedPosts, result.Posted)
+		}
+	}
+
+	// The first run falls back to the count; later runs ask for saves since
+	// the newest one posted, a page at a time
+	expected := []request{{Count: 10}, {Count: pocketPageSize, Since: 1704070800}}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected requests %+v, got %+v", expected, requests)
+	}
+
+	store, err := loadFileStateStore(config.StateFile)
+	if err != nil {
+		t.Fatalf("loadFileStateStore failed: %v", err)
+	}
+	if store.LastSync().Unix() != 1704074400 {
+		t.Errorf("Expected last sync 1704074400, got %d", store.LastSync().Unix())
+	}
+}
+
+func TestAdvanceLastSync_KeepsNewest(t *testing.T) {
+	store, err := loadFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("loadFileStateStore failed: %v", err)
+	}
+	store.SetLastSync(time.Unix(1704070800, 0))
+
+	advanceLastSync(store, []*PocketItem{{TimeAdded: time.Unix(1704067200, 0)}, {}})
+	if store.LastSync().Unix() != 1704070800 {
+		t.Errorf("Expected last sync not to move backwards, got %d", store.LastSync().Unix())
+	}
+}
+
+func TestPostSaves_CancelledMidRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requests int
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			// Shut down while the second post is in flight
+			cancel()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := loadFileStateStore(path)
+	if err != nil {
+		t.Fatalf("loadFileStateStore failed: %v", err)
+	}
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token"}
+	posted, errs, err := postSaves(ctx, config, newTestPoster(t, config), nil, nil, store, 0, []*PocketItem{
+		{ItemID: "123", Title: "Test Article 1", URL: "https://example.com/article1"},
+		{ItemID: "456", Title: "Test Article 2", URL: "https://example.com/article2"},
+		{ItemID: "789", Title: "Test Article 3", URL: "https://example.com/article3"},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected postSaves to stop with context.Canceled, got %v", err)
+	}
+	if posted != 1 || len(errs) != 0 {
+		t.Errorf("Expected 1 posted and 0 failed, got %d and %d", posted, len(errs))
+	}
+	if requests != 2 {
+		t.Errorf("Expected no posts after cancellation, got %d requests", requests)
+	}
+
+	reloaded, err := loadFileStateStore(path)
+	if err != nil {
+		t.Fatalf("loadFileStateStore failed: %v", err)
+	}
+	if !reloaded.Posted("123") || reloaded.Posted("456") || reloaded.Posted("789") {
+		t.Errorf("Expected only item 123 to be saved as posted")
+	}
+}
@@ -0,0 +1,954 @@
+// Package syndicate posts new read-later saves, from Pocket or a compatible
+// source, to a fediverse account. The pocket2fedi command is a thin wrapper
+// around it; other tools can embed a run with Run.
+package syndicate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-mastodon"
+	"github.com/motemen/go-pocket/api"
+	"golang.org/x/text/unicode/norm"
+)
+
+// PocketItem represents a simplified Pocket item structure
+type PocketItem struct {
+	ItemID      string
+	Title       string
+	URL         string // the link to post, see chooseURL
+	GivenURL    string
+	ResolvedURL string
+	ShortURL    string
+	IsArticle   bool
+	HasImage    int // 0 = no image, 1 = has images, 2 = the item is an image
+	HasVideo    int // 0 = no video, 1 = has videos, 2 = the item is a video
+	Excerpt     string
+	Tags        []string
+	TimeAdded   time.Time
+	Favorite    bool
+	ImageURL    string // the article's lead image, if Pocket found one
+	Status      int    // 0 = unread, 1 = archived, 2 = deleted
+}
+
+// Preferences for which of a save's URLs is posted
+const (
+	urlSourceResolved          = "resolved"
+	urlSourceGiven             = "given"
+	urlSourceResolvedThenGiven = "resolved-then-given"
+)
+
+// Pocket content types for POCKET_CONTENT_TYPE
+const (
+	contentTypeArticle = "article"
+	contentTypeVideo   = "video"
+	contentTypeImage   = "image"
+)
+
+// Preferences for which of a Pocket save's titles is posted
+const (
+	titleSourceAuto     = "auto"
+	titleSourceResolved = "resolved"
+	titleSourceGiven    = "given"
+)
+
+// Orders in which a run posts its saves
+const (
+	postOrderNewest = "newest"
+	postOrderOldest = "oldest"
+)
+
+// Policies for saves that are just an image rather than an article
+const (
+	imageItemsPost = "post"
+	imageItemsSkip = "skip"
+)
+
+// chooseURL sets the save's URL from its given or resolved URL according to
+// preference, reporting false if the save lacks the URL asked for
+func (item *PocketItem) chooseURL(preference string) bool {
+	switch preference {
+	case urlSourceGiven:
+		item.URL = item.GivenURL
+	case urlSourceResolvedThenGiven:
+		item.URL = item.ResolvedURL
+		if item.URL == "" {
+			item.URL = item.GivenURL
+		}
+	default:
+		item.URL = item.ResolvedURL
+	}
+	return item.URL != ""
+}
+
+// cwTagPrefix marks a tag that puts the save behind a content warning, e.g.
+// cw:politics
+const cwTagPrefix = "cw:"
+
+// spoiler returns the content warning for the save: the text of its first
+// cw: tag if it has one, or fallback otherwise
+func (item *PocketItem) spoiler(fallback string) string {
+	for _, tag := range item.Tags {
+		if text, ok := strings.CutPrefix(tag, cwTagPrefix); ok && text != "" {
+			return text
+		}
+	}
+	return fallback
+}
+
+// pocketTags returns the item's tag names in a stable order
+func pocketTags(item api.Item) []string {
+	var tags []string
+	for tag := range item.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// bestTitle returns Pocket's resolved title for the item, its given title if
+// that is empty, or the host name of its URL as a last resort
+func bestTitle(item api.Item) string {
+	switch {
+	case item.ResolvedTitle != "":
+		return item.ResolvedTitle
+	case item.GivenTitle != "":
+		return item.GivenTitle
+	case item.ResolvedURL != "":
+		return urlHost(item.ResolvedURL)
+	default:
+		return urlHost(item.GivenURL)
+	}
+}
+
+// pocketTitle returns the item's title from titleSource: only its resolved
+// or only its given title, or for titleSourceAuto whichever bestTitle picks.
+// Without the chosen title it falls back to the host name of the item's URL,
+// which filterSaves skips as untitled.
+func pocketTitle(item api.Item, titleSource string) string {
+	switch titleSource {
+	case titleSourceResolved:
+		item.GivenTitle = ""
+	case titleSourceGiven:
+		item.ResolvedTitle = ""
+	}
+	return bestTitle(item)
+}
+
+// urlHost returns the host name of rawURL, or "" if it has none
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// isImage reports whether the save is an image rather than an article
+func (item *PocketItem) isImage() bool {
+	return !item.IsArticle && item.HasImage == int(api.ItemMediaAttachmentIsMedia)
+}
+
+// hasContentType reports whether the save is of contentType the way Pocket's
+// contentType filter sees it. A save can be more than one type: an article
+// with an embedded video is both an article and a video.
+func (item *PocketItem) hasContentType(contentType string) bool {
+	switch contentType {
+	case contentTypeVideo:
+		return item.HasVideo > int(api.ItemMediaAttachmentNoMedia)
+	case contentTypeImage:
+		return item.isImage()
+	case contentTypeArticle:
+		return item.IsArticle
+	}
+	return false
+}
+
+// getRecentPocketSaves fetches the f.count most recent Pocket saves, or every
+// save changed after since when it is set, up to pocketFetchCap. With
+// f.favorites, Pocket only returns favorited saves, and with f.contentType
+// only saves of that type.
+func getRecentPocketSaves(ctx context.Context, f *pocketFetcher, since time.Time) ([]*PocketItem, error) {
+	client := api.NewClient(f.consumerKey, f.accessToken)
+
+	params := &api.RetrieveOption{
+		Sort:       api.SortNewest,
+		DetailType: api.DetailTypeComplete, // includes tags
+	}
+	total := f.count
+	if !since.IsZero() {
+		// Catch up from the oldest so that if the cap cuts the fetch short,
+		// the last sync stops before the saves that were left out
+		params.Since = int(since.Unix())
+		params.Sort = api.SortOldest
+	}
+	if total < 1 || !since.IsZero() {
+		total = pocketFetchCap
+	}
+	if f.favorites {
+		params.Favorite = api.FavoriteFilterFavorited
+	}
+	if f.contentType != "" {
+		params.ContentType = api.ContentType(f.contentType)
+	}
+
+	items, err := retrievePocketPages(ctx, client, params, total)
+	if err != nil {
+		return nil, err
+	}
+	if !since.IsZero() && len(items) >= total {
+		logger.Info(fmt.Sprintf("Fetched %d Pocket saves, the most for one run; the rest are left for the next run", len(items)), "count", len(items))
+	}
+
+	var recentSaves []*PocketItem
+	for id, item := range items {
+		save := &PocketItem{
+			ItemID:      id,
+			Title:       pocketTitle(item, f.titleSource),
+			GivenURL:    item.GivenURL,
+			ResolvedURL: item.ResolvedURL,
+			IsArticle:   item.IsArticle == 1,
+			HasImage:    int(item.HasImage),
+			HasVideo:    int(item.HasVideo),
+			Excerpt:     item.Excerpt,
+			Tags:        pocketTags(item),
+			TimeAdded:   time.Time(item.TimeAdded),
+			Favorite:    item.Favorite == 1,
+			ImageURL:    pocketImageURL(item),
+			Status:      int(item.Status),
+		}
+		if !save.chooseURL(f.urlSource) {
+			logger.Debug(fmt.Sprintf("Skipping Pocket item %s: it has no %s URL", id, f.urlSource), "item_id", id)
+			continue
+		}
+		recentSaves = append(recentSaves, save)
+	}
+
+	logger.Info(fmt.Sprintf("Successfully retrieved %d recent Pocket saves", len(recentSaves)), "count", len(recentSaves))
+	return recentSaves, nil
+}
+
+// pocketPageSize is the most items Pocket returns for one request
+const pocketPageSize = 30
+
+// pocketFetchCap bounds how many items a fetch of everything changed since the
+// last sync pages through, e.g. when catching up after a long downtime
+var pocketFetchCap = 1000
+
+// retrievePocketPages retrieves up to total items matching params a page at a
+// time, stopping early at a short page or as soon as ctx is done. Items that
+// move between pages while paging, such as when something is saved meanwhile,
+// are only kept once.
+func retrievePocketPages(ctx context.Context, client *api.Client, params *api.RetrieveOption, total int) (map[string]api.Item, error) {
+	items := map[string]api.Item{}
+	for offset := 0; offset < total; offset += pocketPageSize {
+		page := *params
+		page.Count = min(pocketPageSize, total-offset)
+		page.Offset = offset
+
+		output, err := retrievePocketPage(ctx, client, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve Pocket items: %w", err)
+		}
+		for id, item := range output.List {
+			items[id] = item
+		}
+		if len(output.List) < page.Count {
+			break
+		}
+	}
+	return items, nil
+}
+
+// retrievePocketPage retrieves one page of items, giving up once ctx is done.
+// go-pocket's Retrieve takes no context, so an abandoned request carries on in
+// the background until the HTTP timeout ends it.
+func retrievePocketPage(ctx context.Context, client *api.Client, page *api.RetrieveOption) (*api.RetrieveResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		output *api.RetrieveResult
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := client.Retrieve(page)
+		done <- result{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.output, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// prepareSaves rewrites and filters freshly fetched saves ahead of posting
+func prepareSaves(ctx context.Context, config *Config, limiter *fetchLimiter, saves []*PocketItem) []*PocketItem {
+	pages := newPageHeadCache(limiter)
+	if config.Deamp {
+		deampSaves(ctx, pages, saves, config.DeampConfirm)
+	}
+	if config.CanonicalizeURL {
+		canonicalizeSaves(saves)
+	}
+	if config.OGFallback {
+		fillMissingTitles(ctx, pages, saves)
+	}
+	if config.NormalizeUnicode {
+		for _, save := range saves {
+			// Compose characters so rune counts match what Mastodon sees
+			save.Title = norm.NFC.String(save.Title)
+			save.Excerpt = norm.NFC.String(save.Excerpt)
+		}
+	}
+	for _, save := range saves {
+		save.Excerpt = trimExcerpt(save.Excerpt, config.MaxExcerptLength)
+	}
+	return filterSaves(saves, config)
+}
+
+// sortSaves orders saves by when they were added, newest first unless order
+// is postOrderOldest. Saves added at the same time keep their order.
+func sortSaves(saves []*PocketItem, order string) {
+	sort.SliceStable(saves, func(i, j int) bool {
+		if order == postOrderOldest {
+			return saves[i].TimeAdded.Before(saves[j].TimeAdded)
+		}
+		return saves[i].TimeAdded.After(saves[j].TimeAdded)
+	})
+}
+
+// filterSaves drops saves that should not be posted under the configured policies
+func filterSaves(saves []*PocketItem, config *Config) []*PocketItem {
+	var filtered []*PocketItem
+	for _, save := range saves {
+		// An incremental fetch also returns saves archived or deleted since
+		if save.Status != int(api.ItemStatusUnread) {
+			logger.Debug(fmt.Sprintf("Skipping '%s': it has been archived or deleted", save.URL), itemAttrs(save, "status", save.Status)...)
+			continue
+		}
+		if save.isImage() && config.ImageItemPolicy == imageItemsSkip {
+			logger.Debug(fmt.Sprintf("Skipping image save '%s'", save.URL), itemAttrs(save)...)
+			continue
+		}
+		if config.TagAfterPost != "" && save.hasTag(config.TagAfterPost) {
+			logger.Debug(fmt.Sprintf("Skipping '%s': it is tagged %s, so it was already posted", save.URL, config.TagAfterPost), itemAttrs(save)...)
+			continue
+		}
+		if config.ContentType != "" && !save.hasContentType(config.ContentType) {
+			logger.Debug(fmt.Sprintf("Skipping '%s': it isn't of type %s", save.URL, config.ContentType), itemAttrs(save)...)
+			continue
+		}
+		if config.FavoritesOnly && !save.Favorite {
+			logger.Debug(fmt.Sprintf("Skipping '%s': it isn't a favorite", save.URL), itemAttrs(save)...)
+			continue
+		}
+		if isBlocked(save.URL, config.DomainBlocklist) {
+			logger.Debug(fmt.Sprintf("Skipping '%s': its domain is in POCKET_DOMAIN_BLOCKLIST", save.URL), itemAttrs(save)...)
+			continue
+		}
+		if config.URLRegex != nil && !config.URLRegex.MatchString(save.URL) {
+			logger.Debug(fmt.Sprintf("Skipping '%s': URL does not match URL_REGEX", save.URL), itemAttrs(save)...)
+			continue
+		}
+		if config.TitleRegex != nil && !config.TitleRegex.MatchString(save.Title) {
+			logger.Debug(fmt.Sprintf("Skipping '%s': title does not match TITLE_REGEX", save.URL), itemAttrs(save)...)
+			continue
+		}
+		// Pocket leaves both titles empty when it couldn't resolve the page,
+		// and by now POCKET2FEDI_OG_FALLBACK has had its chance to fill them.
+		// Images are left to POCKET2FEDI_IMAGE_ITEMS, as they rarely have one.
+		if !save.isImage() && (save.Title == "" || save.Title == urlHost(save.URL)) {
+			logger.Debug(fmt.Sprintf("Skipping '%s': it has no title", save.URL), itemAttrs(save)...)
+			continue
+		}
+		if config.TitleDenyRegex != nil && config.TitleDenyRegex.MatchString(save.Title) {
+			logger.Debug(fmt.Sprintf("Skipping '%s': title matches POCKET_TITLE_DENY_REGEX", save.URL), itemAttrs(save)...)
+			continue
+		}
+		if config.Quarantine > 0 && time.Since(save.TimeAdded) < config.Quarantine {
+			logger.Debug(fmt.Sprintf("Deferring '%s': saved less than %v ago", save.URL, config.Quarantine), itemAttrs(save)...)
+			continue
+		}
+		// Saves without a known save time are kept
+		if config.SinceDays > 0 && !save.TimeAdded.IsZero() && time.Since(save.TimeAdded) > time.Duration(config.SinceDays)*24*time.Hour {
+			logger.Debug(fmt.Sprintf("Skipping '%s': saved more than %d days ago", save.URL, config.SinceDays), itemAttrs(save)...)
+			continue
+		}
+		filtered = append(filtered, save)
+	}
+	return filtered
+}
+
+// postToMastodon posts toot to Mastodon and returns the created status. Empty
+// toot fields such as Visibility and Language take the account defaults.
+func postToMastodon(ctx context.Context, server, accessToken string, toot *mastodon.Toot) (*mastodon.Status, error) {
+	client := mastodon.NewClient(&mastodon.Config{
+		Server:      server,
+		AccessToken: accessToken,
+	})
+	client.Timeout = httpTimeout
+	client.Transport = mastodonRateLimit
+
+	posted, err := client.PostStatus(ctx, toot)
+
+	if isMaintenanceError(err) {
+		return nil, fmt.Errorf("failed to post to Mastodon: %w: %v", errInstanceMaintenance, err)
+	}
+	if isDuplicateError(err) {
+		return nil, fmt.Errorf("failed to post to Mastodon: %w: %v", errDuplicateStatus, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to post to Mastodon: %w", err)
+	}
+	return posted, nil
+}
+
+// errInstanceMaintenance means the instance is refusing writes for now, so
+// the rest of the run should be deferred rather than retried item by item
+var errInstanceMaintenance = errors.New("Mastodon instance is in maintenance or read-only mode")
+
+// isMaintenanceError reports whether a Mastodon API error indicates the
+// instance is down for maintenance or running read-only
+func isMaintenanceError(err error) bool {
+	var apiErr *mastodon.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.StatusCode == http.StatusServiceUnavailable {
+		return true
+	}
+	message := strings.ToLower(apiErr.Message)
+	return strings.Contains(message, "read-only") || strings.Contains(message, "read only") || strings.Contains(message, "maintenance")
+}
+
+// errDuplicateStatus means the instance already has this status, typically
+// from an earlier run without a state store, so there is nothing to retry
+var errDuplicateStatus = errors.New("status was already posted")
+
+// isDuplicateError reports whether a Mastodon API error is a 422 rejecting
+// the status as a duplicate, as opposed to any other validation failure
+func isDuplicateError(err error) bool {
+	var apiErr *mastodon.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+	return strings.Contains(strings.ToLower(apiErr.Message), "duplicate")
+}
+
+// onlyDuplicate reports whether err rejects the status as a duplicate and
+// nothing else. A fan-out error only counts when every account said so, so
+// a real failure on another account isn't mistaken for one.
+func onlyDuplicate(err error) bool {
+	var fanOutErr *fanOutError
+	if errors.As(err, &fanOutErr) {
+		for _, accountErr := range fanOutErr.errs {
+			if !errors.Is(accountErr, errDuplicateStatus) {
+				return false
+			}
+		}
+		return len(fanOutErr.errs) > 0
+	}
+	return errors.Is(err, errDuplicateStatus)
+}
+
+// checkMastodonHealth probes the instance health endpoint, returning
+// errInstanceMaintenance if it is not serving normally
+func checkMastodonHealth(ctx context.Context, server string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(server, "/")+"/health", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Mastodon health endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: health endpoint returned status %d", errInstanceMaintenance, resp.StatusCode)
+	}
+	return nil
+}
+
+// defaultPostDelay is how long to pause between posts when POST_DELAY isn't
+// set and the instance doesn't report its rate limit
+const defaultPostDelay = 2 * time.Second
+
+// postSaves posts each save with poster and returns how many were posted and
+// an error for each save that failed. Failed posts are logged and collected
+// without stopping the run, but it stops early and returns
+// errInstanceMaintenance if the instance stops accepting posts mid-run.
+// Statuses longer than maxChars are truncated; 0 means no limit. Up to
+// config.Concurrency posts are in flight at once, and once
+// config.MaxPostsPerRun saves are posted the rest are left unposted.
+func postSaves(ctx context.Context, config *Config, poster Poster, limiter *fetchLimiter, prompt *prompter, store StateStore, maxChars int, saves []*PocketItem) (posted int, errs []error, err error) {
+	r := &postRun{
+		config:   config,
+		limiter:  limiter,
+		prompt:   prompt,
+		store:    store,
+		maxChars: maxChars,
+		poster:   poster,
+		workers:  make(chan struct{}, max(config.Concurrency, 1)),
+	}
+	err = r.postAll(ctx, saves)
+	r.wg.Wait()
+
+	// A worker that had to stop saw the problem first
+	if r.halted != nil {
+		err = r.halted
+	}
+	return r.posted, r.errs, err
+}
+
+// postRun is the state shared by the workers posting one batch of saves
+type postRun struct {
+	config   *Config
+	limiter  *fetchLimiter
+	prompt   *prompter
+	store    StateStore
+	maxChars int
+	poster   Poster
+
+	// workers holds a token for each post in flight
+	workers chan struct{}
+	wg      sync.WaitGroup
+
+	mu       sync.Mutex
+	posted   int
+	inFlight int
+	errs     []error
+	halted   error
+}
+
+// postAll prepares each save in order and hands it to a worker to post
+func (r *postRun) postAll(ctx context.Context, saves []*PocketItem) error {
+	for i, save := range saves {
+		// Take a worker before preparing the save, so at concurrency 1 each
+		// save is only handled once the previous post and its rate limit
+		// wait are done
+		r.workers <- struct{}{}
+		if err := ctx.Err(); err != nil {
+			<-r.workers
+			return fmt.Errorf("stopped with %d saves left: %w", len(saves)-i, err)
+		}
+		r.mu.Lock()
+		halted := r.halted != nil
+		r.mu.Unlock()
+		if halted {
+			<-r.workers
+			return nil
+		}
+		if r.atLimit() {
+			<-r.workers
+			logger.Info(fmt.Sprintf("Posted %d saves, the most for one run; leaving %d for the next run", r.config.MaxPostsPerRun, len(saves)-i), "posted", r.config.MaxPostsPerRun, "count", len(saves)-i)
+			return nil
+		}
+
+		dispatched, err := r.handle(ctx, save, len(saves)-i)
+		if !dispatched {
+			<-r.workers
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// atLimit reports whether config.MaxPostsPerRun saves have been posted. When
+// the posts in flight could reach the limit it waits for them first, since
+// any that fail leave room for another save.
+func (r *postRun) atLimit() bool {
+	limit := r.config.MaxPostsPerRun
+	if limit <= 0 {
+		return false
+	}
+
+	r.mu.Lock()
+	pending := r.posted + r.inFlight
+	r.mu.Unlock()
+	if pending >= limit {
+		r.wg.Wait()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.posted >= limit
+}
+
+// handle renders the status for save and, unless it is only previewed,
+// skipped, or written out as JSON, starts a worker to post it. left is how
+// many saves remain including this one.
+func (r *postRun) handle(ctx context.Context, save *PocketItem, left int) (dispatched bool, err error) {
+	config := r.config
+
+	var archiveURL string
+	if config.WaybackMode != "" && !config.DryRun {
+		var err error
+		archiveURL, err = archiveToWayback(ctx, r.limiter, save.URL)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error archiving '%s' to the Wayback Machine, using original link: %v", save.URL, err), itemAttrs(save, "error", err)...)
+		}
+	}
+
+	tmpl := selectTemplate(urlHost(save.URL), config.DomainTemplates, config.StatusTemplate)
+	status, err := formatStatus(save, archiveURL, config.WaybackMode, tmpl)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error formatting status for '%s': %v", save.Title, err), itemAttrs(save, "error", err)...)
+		r.mu.Lock()
+		r.errs = append(r.errs, fmt.Errorf("failed to format status for '%s': %w", save.Title, err))
+		r.mu.Unlock()
+		return false, nil
+	}
+	if config.HashtagsFromTags {
+		if hashtags := tagsToHashtags(save.Tags); hashtags != "" {
+			status += " " + hashtags
+		}
+	}
+	status = appendSuffix(status, config.StatusSuffix, r.maxChars, statusURLLength(config.FediverseType))
+	if config.DryRun {
+		logger.Info(fmt.Sprintf("[dry-run] would post: %s", status), itemAttrs(save, "dry_run", true)...)
+		return false, nil
+	}
+
+	ok, err := r.prompt.confirm(ctx, status)
+	if err != nil {
+		return false, fmt.Errorf("stopped with %d saves left: %w", left, err)
+	}
+	if !ok {
+		logger.Debug(fmt.Sprintf("Skipping '%s' at the prompt", save.Title), itemAttrs(save)...)
+		return false, nil
+	}
+	if config.Output == outputJSON {
+		if err := writeRecord(jsonOutput, save, status); err != nil {
+			return false, err
+		}
+		r.mu.Lock()
+		r.posted++
+		markPosted(r.store, save, "")
+		r.mu.Unlock()
+		return false, nil
+	}
+
+	r.mu.Lock()
+	r.inFlight++
+	r.mu.Unlock()
+	r.wg.Add(1)
+	go r.post(withIdempotencyKey(ctx, idempotencyKey(save, tmpl)), save, status, left)
+	return true, nil
+}
+
+// post posts status for save, records the outcome, and waits out the rate
+// limit before giving up its worker
+func (r *postRun) post(ctx context.Context, save *PocketItem, status string, left int) {
+	defer r.wg.Done()
+	defer func() { <-r.workers }()
+
+	opts := statusOptions{
+		spoiler:  save.spoiler(r.config.SpoilerText),
+		language: statusLanguage(r.config, save),
+		poll:     r.config.Poll,
+	}
+	if r.config.AttachImage {
+		opts.imageURL = save.ImageURL
+	}
+	statusURL, err := postStatus(ctx, r.poster, status, opts)
+
+	r.mu.Lock()
+	r.inFlight--
+	tag := false
+	var fanOutErr *fanOutError
+	switch {
+	case errors.As(err, &fanOutErr) && fanOutErr.posted > 0:
+		// Some accounts took the status. Count it as posted so they don't
+		// get it again next run, but report the accounts that missed it.
+		logger.Error(fmt.Sprintf("Error posting '%s' to some accounts: %v", save.Title, err), itemAttrs(save, "error", err)...)
+		r.errs = append(r.errs, fmt.Errorf("failed to post '%s' to every account: %w", save.Title, err))
+		r.posted++
+		markPosted(r.store, save, statusURL)
+		tag = true
+	case onlyDuplicate(err):
+		// The status is already on the instance; remember the save so it
+		// isn't tried again, but it isn't a new post or a failure
+		logger.Info(fmt.Sprintf("Skipping '%s': Mastodon already has this status", save.Title), itemAttrs(save)...)
+		markPosted(r.store, save, "")
+		tag = true
+	case errors.Is(err, errInstanceMaintenance):
+		if r.halted == nil {
+			r.halted = fmt.Errorf("deferring %d remaining saves: %w", left, err)
+		}
+	case err != nil && ctx.Err() != nil:
+		if r.halted == nil {
+			r.halted = fmt.Errorf("stopped with %d saves left: %w", left, ctx.Err())
+		}
+	case err != nil:
+		logger.Error(fmt.Sprintf("Error posting to Mastodon for '%s': %v", save.Title, err), itemAttrs(save, "error", err)...)
+		r.errs = append(r.errs, fmt.Errorf("failed to post '%s': %w", save.Title, err))
+	default:
+		logger.Debug(fmt.Sprintf("Successfully posted to Mastodon: %s", status), itemAttrs(save)...)
+		r.posted++
+		markPosted(r.store, save, statusURL)
+		tag = true
+	}
+	halted := r.halted != nil
+	r.mu.Unlock()
+	if tag {
+		tagPosted(r.config, save)
+	}
+	if halted {
+		return
+	}
+
+	// Wait as long as the instance's rate limit asks before the next post
+	select {
+	case <-ctx.Done():
+	case <-time.After(mastodonRateLimit.nextDelay(r.config.PostDelay)):
+	}
+}
+
+// markPosted records save in store so later runs skip it, along with the URL
+// it was posted as when the store keeps those
+func markPosted(store StateStore, save *PocketItem, statusURL string) {
+	if store == nil || save.ItemID == "" {
+		return
+	}
+	var err error
+	if urlStore, ok := store.(statusURLStore); ok && statusURL != "" {
+		err = urlStore.MarkPostedAs(save.ItemID, statusURL)
+	} else {
+		err = store.MarkPosted(save.ItemID)
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error recording '%s' as posted, it may be posted again: %v", save.Title, err), itemAttrs(save, "error", err)...)
+	}
+}
+
+// postFailureSummary posts a short summary of the run with the configured
+// visibility, but only if some saves failed to post
+func postFailureSummary(ctx context.Context, config *Config, posted, failed int) error {
+	if config.FailureSummary == "" || failed == 0 {
+		return nil
+	}
+
+	summary := fmt.Sprintf("pocket2fedi run finished with failures: %d posted, %d failed.", posted, failed)
+	summaryConfig := *config
+	summaryConfig.Visibility = config.FailureSummary
+	summaryConfig.Poll = nil
+	summaryConfig.ThreadMode = false
+	summaryConfig.Targets = nil
+	poster, err := NewPoster(&summaryConfig)
+	if err != nil {
+		return err
+	}
+	if _, err := poster.Post(ctx, summary); err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Successfully posted failure summary: %s", summary))
+	return nil
+}
+
+// formatStatus renders the status for save with the configured template. The
+// link in {{.URL}} is the short URL if there is one, or the Wayback snapshot
+// in waybackArchive mode; in waybackBoth mode the snapshot is appended.
+func formatStatus(save *PocketItem, archiveURL, waybackMode, tmpl string) (string, error) {
+	display := *save
+	if save.ShortURL != "" {
+		display.URL = save.ShortURL
+	}
+
+	var suffix string
+	if archiveURL != "" {
+		switch waybackMode {
+		case waybackArchive:
+			display.URL = archiveURL
+		case waybackBoth:
+			suffix = fmt.Sprintf(" (archived: %s)", archiveURL)
+		}
+	}
+
+	status, err := renderStatus(&display, tmpl)
+	if err != nil {
+		return "", err
+	}
+	return status + suffix, nil
+}
+
+// Process exit codes. A run with nothing new to post exits with
+// ExitSuccess unless -nothing-new-code selects a different code.
+const (
+	ExitSuccess = 0 // posted everything, or nothing new
+	ExitFailure = 1 // fetching failed, the run was deferred, or a post failed
+)
+
+// Result summarizes a run so the caller can choose an exit code
+type Result struct {
+	Posted int
+	Errs   []error // one for each save that failed to post
+	Err    error   // set when the run could not fetch or had to be deferred
+}
+
+// ExitCode maps a run result onto the process exit code
+func (r Result) ExitCode(nothingNewCode int) int {
+	if r.Err != nil || len(r.Errs) > 0 {
+		return ExitFailure
+	}
+	if r.Posted == 0 {
+		return nothingNewCode
+	}
+	return ExitSuccess
+}
+
+// Run fetches new saves from source, posts them with poster, and reports the
+// outcome. The state store records what was posted so the next run skips it;
+// with a nil store every fetched save is posted.
+func Run(ctx context.Context, config *Config, source PocketSource, poster Poster, store StateStore) Result {
+	return run(ctx, config, source, poster, nil, store)
+}
+
+// RunInteractive is Run, but shows each status on out and asks on in before
+// posting it
+func RunInteractive(ctx context.Context, config *Config, source PocketSource, poster Poster, store StateStore, in io.Reader, out io.Writer) Result {
+	return run(ctx, config, source, poster, newPrompter(in, out), store)
+}
+
+// run fetches new saves, posts them, and reports the outcome, asking before
+// each post if prompt is set
+func run(ctx context.Context, config *Config, fetcher PocketSource, poster Poster, prompt *prompter, store StateStore) Result {
+	if config.HealthCheck {
+		if err := checkMastodonHealth(ctx, config.MastodonServer); err != nil {
+			logger.Error(fmt.Sprintf("Mastodon instance is not healthy, deferring this run: %v", err), "error", err)
+			return Result{Err: err}
+		}
+	}
+
+	limits := postingLimits(ctx, config)
+
+	recentSaves, err := fetcher.Fetch(ctx, lastSync(store))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error fetching saves: %v", err), "error", err)
+		return Result{Err: err}
+	}
+	metrics.addRun(len(recentSaves), 0, 0)
+	limiter := newFetchLimiter(config.EnrichConcurrency, config.EnrichHostDelay, config.EnrichJitter)
+	recentSaves, held, err := selectSaves(ctx, config, limiter, store, limits.MaxCharacters, recentSaves)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error reading denied items: %v", err), "error", err)
+		return Result{Err: err}
+	}
+	if held {
+		logger.Info(fmt.Sprintf("Holding %d new saves until there are at least %d", len(recentSaves), config.MinBatch), "count", len(recentSaves))
+		return Result{}
+	}
+
+	posted, errs, err := postSaves(ctx, config, poster, limiter, prompt, store, limits.MaxCharacters, recentSaves)
+	metrics.addRun(0, posted, len(errs))
+	if err != nil {
+		logger.Error(fmt.Sprintf("Run stopped early: %v", err), "posted", posted, "failed", len(errs), "error", err)
+		return Result{Posted: posted, Errs: errs, Err: err}
+	}
+
+	// Only move the sync point when nothing failed, was left for later or
+	// was declined at the prompt, so those saves are fetched again next
+	// time; the ones that were posted are skipped by ID
+	limited := config.MaxPostsPerRun > 0 && posted >= config.MaxPostsPerRun
+	if len(errs) == 0 && !limited && !prompt.declinedAny() && !config.DryRun {
+		advanceLastSync(store, recentSaves)
+	}
+
+	if err := postFailureSummary(ctx, config, posted, len(errs)); err != nil {
+		logger.Error(fmt.Sprintf("Error posting failure summary: %v", err), "error", err)
+	}
+
+	if len(errs) > 0 {
+		logger.Error(fmt.Sprintf("%d of %d saves failed to post:\n%v", len(errs), len(recentSaves), errors.Join(errs...)), "failed", len(errs))
+	}
+	logger.Info("Finished processing recent Pocket saves.", "posted", posted, "failed", len(errs))
+	return Result{Posted: posted, Errs: errs}
+}
+
+// holdBatch reports whether saves should wait for a later run because there
+// are fewer than minBatch of them. Once the oldest has waited longer than
+// maxHold the batch is released anyway; a zero maxHold waits indefinitely.
+func holdBatch(saves []*PocketItem, minBatch int, maxHold time.Duration) bool {
+	if len(saves) == 0 || len(saves) >= minBatch {
+		return false
+	}
+	if maxHold > 0 {
+		for _, save := range saves {
+			if !save.TimeAdded.IsZero() && time.Since(save.TimeAdded) >= maxHold {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// postingLimits returns the limits statuses are posted under. Only
+// Mastodon-compatible servers serve the instance API; Misskey gets the
+// default limits and Bluesky its own. The configured poll is fitted to them.
+func postingLimits(ctx context.Context, config *Config) *instanceLimits {
+	limits := &defaultInstanceLimits
+	if config.FediverseType == fediverseBluesky {
+		limits = &blueskyLimits
+	} else if config.FediverseType != fediverseMisskey && ((config.Output == outputMastodon && !config.DryRun) || config.Poll != nil || config.LongURLPolicy != "") {
+		fetched, err := fetchInstanceLimits(ctx, config.MastodonServer, config.MastodonToken)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error fetching instance limits, using defaults: %v", err), "error", err)
+		} else {
+			limits = fetched
+		}
+		config.Poll = limits.fitPoll(config.Poll)
+	}
+	return limits
+}
+
+// selectSaves narrows freshly fetched saves down to the ones a run posts, in
+// the order it posts them. held reports that they are too few to post yet
+// under config.MinBatch.
+func selectSaves(ctx context.Context, config *Config, limiter *fetchLimiter, store StateStore, maxChars int, saves []*PocketItem) (selected []*PocketItem, held bool, err error) {
+	saves, err = skipDeniedItems(saves, config.DeniedItemsFile)
+	if err != nil {
+		return nil, false, err
+	}
+	saves = skipPosted(saves, store)
+	saves = prepareSaves(ctx, config, limiter, saves)
+	saves = applyLongURLPolicy(ctx, config, limiter, maxChars, saves)
+	sortSaves(saves, config.PostOrder)
+	return saves, holdBatch(saves, config.MinBatch, config.MinBatchMaxHold), nil
+}
+
+// CountNewItems reports how many saves would be posted, without posting them
+func CountNewItems(ctx context.Context, config *Config, fetcher PocketSource, store StateStore) (int, error) {
+	limits := postingLimits(ctx, config)
+	recentSaves, err := fetcher.Fetch(ctx, lastSync(store))
+	if err != nil {
+		return 0, err
+	}
+	limiter := newFetchLimiter(config.EnrichConcurrency, config.EnrichHostDelay, config.EnrichJitter)
+	recentSaves, held, err := selectSaves(ctx, config, limiter, store, limits.MaxCharacters, recentSaves)
+	if err != nil {
+		return 0, err
+	}
+	if held {
+		return 0, nil
+	}
+	if config.MaxPostsPerRun > 0 {
+		return min(len(recentSaves), config.MaxPostsPerRun), nil
+	}
+	return len(recentSaves), nil
+}
@@ -0,0 +1,1628 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-mastodon"
+	"github.com/motemen/go-pocket/api"
+)
+
+func TestLoadConfigFromEnv_Success(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+
+	_, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Errorf("LoadConfigFromEnv failed: %v", err)
+	}
+
+	os.Unsetenv("POCKET_CONSUMER_KEY")
+	os.Unsetenv("POCKET_ACCESS_TOKEN")
+	os.Unsetenv("MASTODON_SERVER")
+	os.Unsetenv("MASTODON_TOKEN")
+}
+
+func TestLoadConfigFromEnv_MissingVariable(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+
+	_, err := LoadConfigFromEnv()
+	if err == nil {
+		t.Errorf("LoadConfigFromEnv should have failed with missing variable")
+	}
+
+	os.Unsetenv("POCKET_CONSUMER_KEY")
+}
+
+func TestGetRecentPocketSaves_Success(t *testing.T) {
+	// Mock Pocket API response
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"list": {
+				"123": {
+					"resolved_title": "Test Article 1",
+					"resolved_url": "https://example.com/article1",
+					"status": "0",
+					"time_added": "1704067200"
+				},
+				"456": {
+					"resolved_title": "Test Article 2",
+					"resolved_url": "https://example.com/article2",
+					"status": "2"
+				}
+			}
+		}`))
+	}))
+	defer mockPocketServer.Close()
+
+	// Temporarily patch the Pocket API endpoint for testing
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	ctx := context.Background()
+	consumerKey := "test_consumer_key"
+	accessToken := "test_access_token"
+
+	saves, err := getRecentPocketSaves(ctx, &pocketFetcher{consumerKey: consumerKey, accessToken: accessToken, urlSource: urlSourceResolved, titleSource: titleSourceAuto, count: 10}, time.Time{})
+	if err != nil {
+		t.Fatalf("getRecentPocketSaves failed: %v", err)
+	}
+	saves = filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost})
+
+	if len(saves) != 1 {
+		t.Errorf("Expected 1 save, got %d", len(saves))
+	}
+
+	if saves[0].Title != "Test Article 1" {
+		t.Errorf("Expected title 'Test Article 1', got '%s'", saves[0].Title)
+	}
+
+	if saves[0].URL != "https://example.com/article1" {
+		t.Errorf("Expected URL 'https://example.com/article1', got '%s'", saves[0].URL)
+	}
+
+	if saves[0].ItemID != "123" {
+		t.Errorf("Expected item ID '123', got '%s'", saves[0].ItemID)
+	}
+
+	if saves[0].TimeAdded.Unix() != 1704067200 {
+		t.Errorf("Expected time added 1704067200, got %d", saves[0].TimeAdded.Unix())
+	}
+}
+
+// pagedPocketServer serves total saves, numbered from 1, a page at a time
+// according to each request's count and offset. With overlap, every page after
+// the first repeats the last item of the page before, as when a new save
+// shifts everything down while paging.
+func pagedPocketServer(t *testing.T, total int, overlap bool, requests *[][2]int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Count  int `json:"count"`
+			Offset int `json:"offset"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		*requests = append(*requests, [2]int{req.Count, req.Offset})
+
+		start := req.Offset
+		if overlap && start > 0 {
+			start--
+		}
+		list := map[string]any{}
+		for i := start; i < start+req.Count && i < total; i++ {
+			id := strconv.Itoa(i + 1)
+			list[id] = map[string]string{"resolved_title": "Article " + id, "resolved_url": "https://example.com/" + id, "status": "0"}
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]any{"status": 1, "list": list})
+	}))
+}
+
+func TestGetRecentPocketSaves_Pagination(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    int
+		count    int
+		since    time.Time
+		cap      int
+		overlap  bool
+		expected int
+		requests [][2]int
+	}{
+		{"everything since the last sync", 75, 10, time.Unix(1704067200, 0), 1000, false, 75, [][2]int{{30, 0}, {30, 30}, {30, 60}}},
+		{"a count above the page size", 75, 45, time.Time{}, 1000, false, 45, [][2]int{{30, 0}, {15, 30}}},
+		{"a count below the page size", 75, 10, time.Time{}, 1000, false, 10, [][2]int{{10, 0}}},
+		{"stopping at the cap", 75, 10, time.Unix(1704067200, 0), 40, false, 40, [][2]int{{30, 0}, {10, 30}}},
+		{"an exact multiple of the page size", 60, 10, time.Unix(1704067200, 0), 1000, false, 60, [][2]int{{30, 0}, {30, 30}, {30, 60}}},
+		{"pages that overlap", 75, 10, time.Unix(1704067200, 0), 1000, true, 75, [][2]int{{30, 0}, {30, 30}, {30, 60}}},
+	}
+
+	originalEndpoint := api.Origin
+	defer func() { api.Origin = originalEndpoint }()
+	originalCap := pocketFetchCap
+	defer func() { pocketFetchCap = originalCap }()
+
+	for _, tt := range tests {
+		var requests [][2]int
+		mockPocketServer := pagedPocketServer(t, tt.total, tt.overlap, &requests)
+		api.Origin = mockPocketServer.URL
+		pocketFetchCap = tt.cap
+
+		saves, err := getRecentPocketSaves(context.Background(), &pocketFetcher{consumerKey: "test_consumer_key", accessToken: "test_access_token", urlSource: urlSourceResolved, titleSource: titleSourceAuto, count: tt.count}, tt.since)
+		mockPocketServer.Close()
+		if err != nil {
+			t.Fatalf("%s: getRecentPocketSaves failed: %v", tt.name, err)
+		}
+
+		if len(saves) != tt.expected {
+			t.Errorf("%s: expected %d saves, got %d", tt.name, tt.expected, len(saves))
+		}
+		seen := map[string]bool{}
+		for _, save := range saves {
+			if seen[save.ItemID] {
+				t.Errorf("%s: expected each save once, got %s twice", tt.name, save.ItemID)
+			}
+			seen[save.ItemID] = true
+		}
+		if !reflect.DeepEqual(requests, tt.requests) {
+			t.Errorf("%s: expected requests (count, offset) %v, got %v", tt.name, tt.requests, requests)
+		}
+	}
+}
+
+func TestGetRecentPocketSaves_StopsWhenCancelled(t *testing.T) {
+	var requests [][2]int
+	mockPocketServer := pagedPocketServer(t, 75, false, &requests)
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := getRecentPocketSaves(ctx, &pocketFetcher{consumerKey: "test_consumer_key", accessToken: "test_access_token", urlSource: urlSourceResolved, titleSource: titleSourceAuto, count: 10}, time.Unix(1704067200, 0)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("Expected no requests once cancelled, got %v", requests)
+	}
+}
+
+func TestGetRecentPocketSaves_CancelsInFlightRequest(t *testing.T) {
+	mockPocketServer := slowServer(t, 5*time.Second, `{"status": 1, "list": {}}`)
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := getRecentPocketSaves(ctx, &pocketFetcher{consumerKey: "test_consumer_key", accessToken: "test_access_token", urlSource: urlSourceResolved, titleSource: titleSourceAuto, count: 10}, time.Time{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the fetch to give up once cancelled, took %v", elapsed)
+	}
+}
+
+func TestGetRecentPocketSaves_SinceSortsOldestFirst(t *testing.T) {
+	var sorts []string
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Sort string `json:"sort"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		sorts = append(sorts, req.Sort)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": 1, "list": {}}`))
+	}))
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	for _, since := range []time.Time{{}, time.Unix(1704067200, 0)} {
+		if _, err := getRecentPocketSaves(context.Background(), &pocketFetcher{consumerKey: "test_consumer_key", accessToken: "test_access_token", urlSource: urlSourceResolved, titleSource: titleSourceAuto, count: 10}, since); err != nil {
+			t.Fatalf("getRecentPocketSaves failed: %v", err)
+		}
+	}
+
+	// A capped catch-up keeps the oldest saves, so the last sync can't move
+	// past the ones it left out
+	expected := []string{"newest", "oldest"}
+	if !reflect.DeepEqual(sorts, expected) {
+		t.Errorf("Expected sorts %v, got %v", expected, sorts)
+	}
+}
+
+func TestBestTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     api.Item
+		expected string
+	}{
+		{"resolved title", api.Item{ResolvedTitle: "Resolved", GivenTitle: "Given", ResolvedURL: "https://example.com/a"}, "Resolved"},
+		{"given title", api.Item{GivenTitle: "Given", ResolvedURL: "https://example.com/a"}, "Given"},
+		{"resolved URL host", api.Item{ResolvedURL: "https://www.example.com/a", GivenURL: "https://example.org/a"}, "www.example.com"},
+		{"given URL host", api.Item{GivenURL: "https://example.org/a"}, "example.org"},
+		{"nothing to go on", api.Item{}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := bestTitle(tt.item); got != tt.expected {
+			t.Errorf("%s: expected '%s', got '%s'", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestGetRecentPocketSaves_TitleFallback(t *testing.T) {
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"list": {
+				"1": {"resolved_title": "Resolved", "given_title": "Given", "resolved_url": "https://example.com/1", "status": "0"},
+				"2": {"resolved_title": "", "given_title": "Given", "resolved_url": "https://example.com/2", "status": "0"},
+				"3": {"resolved_title": "", "given_title": "", "resolved_url": "https://example.com/3", "status": "0"}
+			}
+		}`))
+	}))
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	saves, err := getRecentPocketSaves(context.Background(), &pocketFetcher{consumerKey: "test_consumer_key", accessToken: "test_access_token", urlSource: urlSourceResolved, titleSource: titleSourceAuto, count: 10}, time.Time{})
+	if err != nil {
+		t.Fatalf("getRecentPocketSaves failed: %v", err)
+	}
+
+	titles := map[string]string{}
+	for _, save := range saves {
+		titles[save.ItemID] = save.Title
+	}
+	expected := map[string]string{"1": "Resolved", "2": "Given", "3": "example.com"}
+	for id, title := range expected {
+		if titles[id] != title {
+			t.Errorf("Item %s: expected title '%s', got '%s'", id, title, titles[id])
+		}
+	}
+}
+
+func TestGetRecentPocketSaves_TitleSource(t *testing.T) {
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"list": {
+				"1": {"resolved_title": "Home | Example", "given_title": "A Better Title", "resolved_url": "https://example.com/1", "status": "0"},
+				"2": {"resolved_title": "Resolved Only", "given_title": "", "resolved_url": "https://example.com/2", "status": "0"},
+				"3": {"resolved_title": "", "given_title": "Given Only", "resolved_url": "https://example.com/3", "status": "0"}
+			}
+		}`))
+	}))
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	tests := []struct {
+		titleSource string
+		expected    map[string]string
+		posted      []string
+	}{
+		{titleSourceAuto, map[string]string{"1": "Home | Example", "2": "Resolved Only", "3": "Given Only"}, []string{"1", "2", "3"}},
+		{titleSourceResolved, map[string]string{"1": "Home | Example", "2": "Resolved Only", "3": "example.com"}, []string{"1", "2"}},
+		{titleSourceGiven, map[string]string{"1": "A Better Title", "2": "example.com", "3": "Given Only"}, []string{"1", "3"}},
+	}
+
+	for _, tt := range tests {
+		saves, err := getRecentPocketSaves(context.Background(), &pocketFetcher{consumerKey: "test_consumer_key", accessToken: "test_access_token", urlSource: urlSourceResolved, titleSource: tt.titleSource, count: 10}, time.Time{})
+		if err != nil {
+			t.Fatalf("getRecentPocketSaves failed: %v", err)
+		}
+
+		titles := map[string]string{}
+		for _, save := range saves {
+			titles[save.ItemID] = save.Title
+		}
+		if !reflect.DeepEqual(titles, tt.expected) {
+			t.Errorf("POCKET2FEDI_TITLE_SOURCE=%s: expected titles %v, got %v", tt.titleSource, tt.expected, titles)
+		}
+
+		// A save left with only its host name for a title is skipped
+		var posted []string
+		for _, save := range filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost}) {
+			posted = append(posted, save.ItemID)
+		}
+		sort.Strings(posted)
+		if !reflect.DeepEqual(posted, tt.posted) {
+			t.Errorf("POCKET2FEDI_TITLE_SOURCE=%s: expected saves %v to be kept, got %v", tt.titleSource, tt.posted, posted)
+		}
+	}
+}
+
+func TestGetRecentPocketSaves_FavoritesOnly(t *testing.T) {
+	var favoriteFilter string
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Favorite string `json:"favorite"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		favoriteFilter = req.Favorite
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"list": {
+				"1": {"resolved_title": "Favorite", "resolved_url": "https://example.com/1", "status": "0", "favorite": "1"},
+				"2": {"resolved_title": "Not a favorite", "resolved_url": "https://example.com/2", "status": "0", "favorite": "0"}
+			}
+		}`))
+	}))
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	saves, err := getRecentPocketSaves(context.Background(), &pocketFetcher{consumerKey: "test_consumer_key", accessToken: "test_access_token", urlSource: urlSourceResolved, titleSource: titleSourceAuto, count: 10, favorites: true}, time.Time{})
+	if err != nil {
+		t.Fatalf("getRecentPocketSaves failed: %v", err)
+	}
+	if favoriteFilter != "1" {
+		t.Errorf("Expected Pocket to be asked for favorites only, got favorite=%q", favoriteFilter)
+	}
+
+	// Pocket's filter is backed up by dropping anything else it returns
+	filtered := filterSaves(saves, &Config{FavoritesOnly: true})
+	if len(filtered) != 1 || filtered[0].Title != "Favorite" {
+		t.Errorf("Expected only the favorite to be kept, got %+v", filtered)
+	}
+	if got := filterSaves(saves, &Config{}); len(got) != 2 {
+		t.Errorf("Expected both saves to be kept without POCKET_FAVORITES_ONLY, got %d", len(got))
+	}
+}
+
+func TestGetRecentPocketSaves_ContentType(t *testing.T) {
+	var contentType string
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ContentType string `json:"contentType"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		contentType = req.ContentType
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"list": {
+				"1": {"resolved_title": "Article", "resolved_url": "https://example.com/1", "status": "0", "is_article": "1", "has_video": "0"},
+				"2": {"resolved_title": "Video", "resolved_url": "https://www.youtube.com/watch?v=2", "status": "0", "is_article": "0", "has_video": "2"},
+				"3": {"resolved_title": "Image", "resolved_url": "https://example.com/3.jpg", "status": "0", "is_article": "0", "has_image": "2"},
+				"4": {"resolved_title": "Article with Video", "resolved_url": "https://example.com/4", "status": "0", "is_article": "1", "has_video": "2"},
+				"5": {"resolved_title": "Page with Video", "resolved_url": "https://example.com/5", "status": "0", "is_article": "0", "has_video": "1"}
+			}
+		}`))
+	}))
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	saves, err := getRecentPocketSaves(context.Background(), &pocketFetcher{consumerKey: "test_consumer_key", accessToken: "test_access_token", urlSource: urlSourceResolved, titleSource: titleSourceAuto, count: 10, contentType: contentTypeArticle}, time.Time{})
+	if err != nil {
+		t.Fatalf("getRecentPocketSaves failed: %v", err)
+	}
+	if contentType != contentTypeArticle {
+		t.Errorf("Expected Pocket to be asked for articles only, got contentType=%q", contentType)
+	}
+
+	// Pocket's filter is backed up by dropping anything else it returns. Like
+	// Pocket, a video is anything with a video in it, and an article with
+	// one is still an article.
+	for _, tt := range []struct {
+		contentType string
+		expected    []string
+	}{
+		{contentTypeArticle, []string{"Article", "Article with Video"}},
+		{contentTypeVideo, []string{"Article with Video", "Page with Video", "Video"}},
+		{contentTypeImage, []string{"Image"}},
+	} {
+		var titles []string
+		for _, save := range filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost, ContentType: tt.contentType}) {
+			titles = append(titles, save.Title)
+		}
+		sort.Strings(titles)
+		if !reflect.DeepEqual(titles, tt.expected) {
+			t.Errorf("POCKET_CONTENT_TYPE=%s: expected %v, got %v", tt.contentType, tt.expected, titles)
+		}
+	}
+	if got := filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost}); len(got) != 5 {
+		t.Errorf("Expected every save without POCKET_CONTENT_TYPE, got %d", len(got))
+	}
+}
+
+func TestRun_ContentType(t *testing.T) {
+	config := &Config{FediverseType: fediverseMisskey, Output: outputMastodon, ImageItemPolicy: imageItemsPost, StatusTemplate: defaultStatusTemplate, ContentType: contentTypeArticle}
+	source := &FakeSource{Items: []*PocketItem{
+		{ItemID: "1", Title: "Article", URL: "https://example.com/1", IsArticle: true},
+		{ItemID: "2", Title: "Video", URL: "https://www.youtube.com/watch?v=2", HasVideo: 2},
+		{ItemID: "3", Title: "Another Article", URL: "https://example.com/3", IsArticle: true},
+	}}
+	poster := &FakePoster{}
+	if result := run(context.Background(), config, source, poster, nil, nil); result.Posted != 2 {
+		t.Fatalf("Expected 2 posts, got %+v", result)
+	}
+	for _, status := range poster.Statuses() {
+		if strings.Contains(status, "youtube.com") {
+			t.Errorf("Expected the video not to be posted, got '%s'", status)
+		}
+	}
+}
+
+func TestGetRecentPocketSaves_URLSource(t *testing.T) {
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"status": 1,
+			"list": {
+				"123": {"given_url": "https://example.com/given", "resolved_url": "https://example.com/resolved", "status": "0"},
+				"456": {"given_url": "https://example.com/given-only", "resolved_url": "", "status": "0"},
+				"789": {"given_url": "", "resolved_url": "https://example.com/resolved-only", "status": "0"}
+			}
+		}`))
+	}))
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	tests := []struct {
+		urlSource string
+		expected  map[string]string
+	}{
+		{urlSourceResolved, map[string]string{"123": "https://example.com/resolved", "789": "https://example.com/resolved-only"}},
+		{urlSourceGiven, map[string]string{"123": "https://example.com/given", "456": "https://example.com/given-only"}},
+		{urlSourceResolvedThenGiven, map[string]string{
+			"123": "https://example.com/resolved",
+			"456": "https://example.com/given-only",
+			"789": "https://example.com/resolved-only",
+		}},
+	}
+
+	for _, tt := range tests {
+		saves, err := getRecentPocketSaves(context.Background(), &pocketFetcher{consumerKey: "test_consumer_key", accessToken: "test_access_token", urlSource: tt.urlSource, titleSource: titleSourceAuto, count: 10}, time.Time{})
+		if err != nil {
+			t.Fatalf("getRecentPocketSaves failed: %v", err)
+		}
+
+		urls := map[string]string{}
+		for _, save := range saves {
+			urls[save.ItemID] = save.URL
+		}
+		if !reflect.DeepEqual(urls, tt.expected) {
+			t.Errorf("%s: expected URLs %v, got %v", tt.urlSource, tt.expected, urls)
+		}
+	}
+}
+
+func TestGetRecentPocketSaves_Failure(t *testing.T) {
+	// Mock Pocket API returning an error
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mockPocketServer.Close()
+
+	// Temporarily patch the Pocket API endpoint for testing
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	ctx := context.Background()
+	consumerKey := "test_consumer_key"
+	accessToken := "test_access_token"
+
+	_, err := getRecentPocketSaves(ctx, &pocketFetcher{consumerKey: consumerKey, accessToken: accessToken, urlSource: urlSourceResolved, titleSource: titleSourceAuto, count: 10}, time.Time{})
+	if err == nil {
+		t.Errorf("getRecentPocketSaves should have failed")
+	}
+}
+
+func TestPostToMastodon_Success(t *testing.T) {
+	// Mock Mastodon API response
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	ctx := context.Background()
+	server := mockMastodonServer.URL
+	accessToken := "test_mastodon_token"
+	status := "Test Mastodon post"
+
+	_, err := postToMastodon(ctx, server, accessToken, &mastodon.Toot{Status: status})
+	if err != nil {
+		t.Errorf("postToMastodon failed: %v", err)
+	}
+}
+
+func TestPostToMastodon_Failure(t *testing.T) {
+	// Mock Mastodon API returning an error
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mockMastodonServer.Close()
+
+	ctx := context.Background()
+	server := mockMastodonServer.URL
+	accessToken := "test_mastodon_token"
+	status := "Test Mastodon post"
+
+	_, err := postToMastodon(ctx, server, accessToken, &mastodon.Toot{Status: status})
+	if err == nil {
+		t.Errorf("postToMastodon should have failed")
+	}
+}
+
+func TestLoadConfigFromEnv_InvalidWaybackMode(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+	os.Setenv("POCKET2FEDI_WAYBACK", "sometimes")
+	defer func() {
+		os.Unsetenv("POCKET_CONSUMER_KEY")
+		os.Unsetenv("POCKET_ACCESS_TOKEN")
+		os.Unsetenv("MASTODON_SERVER")
+		os.Unsetenv("MASTODON_TOKEN")
+		os.Unsetenv("POCKET2FEDI_WAYBACK")
+	}()
+
+	_, err := LoadConfigFromEnv()
+	if err == nil {
+		t.Errorf("LoadConfigFromEnv should have rejected an invalid Wayback mode")
+	}
+}
+
+func TestCountNewItems(t *testing.T) {
+	fetcher := &FakeSource{Items: []*PocketItem{
+		{Title: "Test Article 1", URL: "https://example.com/article1", IsArticle: true},
+		{Title: "Test Article 2", URL: "https://other.example/article2", IsArticle: true},
+		{Title: "Test Article 3", URL: "https://example.com/article3", IsArticle: true},
+	}}
+	config := &Config{URLRegex: regexp.MustCompile(`^https://example\.com/`)}
+
+	count, err := CountNewItems(context.Background(), config, fetcher, nil)
+	if err != nil {
+		t.Fatalf("CountNewItems failed: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("Expected 2 new items, got %d", count)
+	}
+}
+
+func TestCountNewItems_MatchesRun(t *testing.T) {
+	items := []*PocketItem{
+		{ItemID: "1", Title: "Test Article 1", URL: "https://example.com/article1", IsArticle: true},
+		{ItemID: "2", Title: "Test Article 2", URL: "https://example.com/article2", IsArticle: true},
+		{ItemID: "3", Title: "Test Article 3", URL: "https://example.com/article3", IsArticle: true},
+		{ItemID: "4", Title: "Long Article", URL: "https://example.com/" + strings.Repeat("a", 100), IsArticle: true},
+	}
+	tests := []struct {
+		name     string
+		config   Config
+		expected int
+	}{
+		{"long URLs skipped", Config{LongURLPolicy: longURLsSkip, LongURLPercent: 10}, 3},
+		{"capped by MAX_POSTS_PER_RUN", Config{MaxPostsPerRun: 2}, 2},
+		{"held for MIN_BATCH", Config{MinBatch: 5}, 0},
+	}
+
+	for _, tt := range tests {
+		config := tt.config
+		config.FediverseType = fediverseMisskey
+		config.Output = outputMastodon
+		config.ImageItemPolicy = imageItemsPost
+		config.StatusTemplate = defaultStatusTemplate
+
+		count, err := CountNewItems(context.Background(), &config, &FakeSource{Items: items}, nil)
+		if err != nil {
+			t.Fatalf("%s: CountNewItems failed: %v", tt.name, err)
+		}
+		if count != tt.expected {
+			t.Errorf("%s: expected %d new items, got %d", tt.name, tt.expected, count)
+		}
+
+		if result := run(context.Background(), &config, &FakeSource{Items: items}, &FakePoster{}, nil, nil); result.Posted != count {
+			t.Errorf("%s: expected run to post the %d counted, got %+v", tt.name, count, result)
+		}
+	}
+}
+
+func TestFilterSaves_Quarantine(t *testing.T) {
+	saves := []*PocketItem{
+		{Title: "Old Article", URL: "https://example.com/old", IsArticle: true, TimeAdded: time.Now().Add(-2 * time.Hour)},
+		{Title: "New Article", URL: "https://example.com/new", IsArticle: true, TimeAdded: time.Now().Add(-10 * time.Minute)},
+	}
+
+	filtered := filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost, Quarantine: time.Hour})
+	if len(filtered) != 1 || filtered[0].Title != "Old Article" {
+		t.Errorf("Expected only the save outside the quarantine window, got %+v", filtered)
+	}
+
+	filtered = filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost})
+	if len(filtered) != 2 {
+		t.Errorf("Expected no quarantine by default, got %d saves", len(filtered))
+	}
+}
+
+func TestFilterSaves_SinceDays(t *testing.T) {
+	day := 24 * time.Hour
+	var saves []*PocketItem
+	for _, days := range []int{0, 1, 3, 6, 8, 14, 30} {
+		saves = append(saves, &PocketItem{Title: fmt.Sprintf("%d days old", days), URL: "https://example.com/article", IsArticle: true, TimeAdded: time.Now().Add(-time.Duration(days)*day - time.Hour)})
+	}
+	// Saves without a save time can't be judged and are kept
+	saves = append(saves, &PocketItem{Title: "Unknown age", URL: "https://example.com/unknown", IsArticle: true})
+
+	filtered := filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost, SinceDays: 7})
+	var titles []string
+	for _, save := range filtered {
+		titles = append(titles, save.Title)
+	}
+	expected := []string{"0 days old", "1 days old", "3 days old", "6 days old", "Unknown age"}
+	if !reflect.DeepEqual(titles, expected) {
+		t.Errorf("Expected %q, got %q", expected, titles)
+	}
+
+	filtered = filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost})
+	if len(filtered) != len(saves) {
+		t.Errorf("Expected no age limit by default, got %d of %d saves", len(filtered), len(saves))
+	}
+}
+
+func TestLoadConfigFromEnv_SinceDays(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+	defer func() {
+		os.Unsetenv("POCKET_CONSUMER_KEY")
+		os.Unsetenv("POCKET_ACCESS_TOKEN")
+		os.Unsetenv("MASTODON_SERVER")
+		os.Unsetenv("MASTODON_TOKEN")
+		os.Unsetenv("POCKET_SINCE_DAYS")
+	}()
+
+	os.Setenv("POCKET_SINCE_DAYS", "7")
+	config, err := LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("LoadConfigFromEnv failed: %v", err)
+	}
+	if config.SinceDays != 7 {
+		t.Errorf("Expected SinceDays 7, got %d", config.SinceDays)
+	}
+
+	os.Setenv("POCKET_SINCE_DAYS", "-1")
+	if _, err := LoadConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "POCKET_SINCE_DAYS") {
+		t.Errorf("Expected an error mentioning POCKET_SINCE_DAYS, got %v", err)
+	}
+}
+
+func TestGetRecentPocketSaves_ImageItems(t *testing.T) {
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"list": {
+				"123": {"resolved_title": "Test Article", "resolved_url": "https://example.com/article", "status": "0", "is_article": "1", "has_image": "1",
+					"tags": {"golang": {"item_id": "123", "tag": "golang"}, "cw:politics": {"item_id": "123", "tag": "cw:politics"}}},
+				"456": {"resolved_title": "", "resolved_url": "https://example.com/photo.jpg", "status": "0", "is_article": "0", "has_image": "2"}
+			}
+		}`))
+	}))
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	saves, err := getRecentPocketSaves(context.Background(), &pocketFetcher{consumerKey: "test_consumer_key", accessToken: "test_access_token", urlSource: urlSourceResolved, titleSource: titleSourceAuto, count: 10}, time.Time{})
+	if err != nil {
+		t.Fatalf("getRecentPocketSaves failed: %v", err)
+	}
+
+	images := 0
+	for _, save := range saves {
+		if save.isImage() {
+			images++
+			if save.URL != "https://example.com/photo.jpg" {
+				t.Errorf("Expected image URL 'https://example.com/photo.jpg', got '%s'", save.URL)
+			}
+		} else if !reflect.DeepEqual(save.Tags, []string{"cw:politics", "golang"}) {
+			t.Errorf("Expected sorted tags [cw:politics golang], got %v", save.Tags)
+		}
+	}
+	if images != 1 {
+		t.Errorf("Expected 1 image save, got %d", images)
+	}
+}
+
+func TestFilterSaves_ImageItemPolicy(t *testing.T) {
+	saves := []*PocketItem{
+		{Title: "Test Article", URL: "https://example.com/article", IsArticle: true, HasImage: 1},
+		{Title: "", URL: "https://example.com/photo.jpg", HasImage: 2},
+	}
+
+	posted := filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost})
+	if len(posted) != 2 {
+		t.Errorf("Expected 2 saves with policy '%s', got %d", imageItemsPost, len(posted))
+	}
+
+	skipped := filterSaves(saves, &Config{ImageItemPolicy: imageItemsSkip})
+	if len(skipped) != 1 {
+		t.Fatalf("Expected 1 save with policy '%s', got %d", imageItemsSkip, len(skipped))
+	}
+	if skipped[0].URL != "https://example.com/article" {
+		t.Errorf("Expected the article to remain, got '%s'", skipped[0].URL)
+	}
+}
+
+func TestFilterSaves_Regex(t *testing.T) {
+	saves := []*PocketItem{
+		{Title: "Go 1.23 released", URL: "https://go.dev/blog/go1.23", IsArticle: true},
+		{Title: "Rust 1.80 released", URL: "https://blog.rust-lang.org/1.80", IsArticle: true},
+		{Title: "Generics in Go", URL: "https://example.com/generics", IsArticle: true},
+	}
+
+	tests := []struct {
+		name       string
+		urlRegex   string
+		titleRegex string
+		expected   []string
+	}{
+		{"no filters", "", "", []string{"https://go.dev/blog/go1.23", "https://blog.rust-lang.org/1.80", "https://example.com/generics"}},
+		{"url only", `^https://go\.dev/`, "", []string{"https://go.dev/blog/go1.23"}},
+		{"title only", "", `\bGo\b`, []string{"https://go.dev/blog/go1.23", "https://example.com/generics"}},
+		{"url and title", `example\.com`, `(?i)generics`, []string{"https://example.com/generics"}},
+		{"nothing matches", `example\.com`, `Rust`, nil},
+	}
+
+	for _, tt := range tests {
+		config := &Config{ImageItemPolicy: imageItemsPost}
+		if tt.urlRegex != "" {
+			config.URLRegex = regexp.MustCompile(tt.urlRegex)
+		}
+		if tt.titleRegex != "" {
+			config.TitleRegex = regexp.MustCompile(tt.titleRegex)
+		}
+
+		filtered := filterSaves(saves, config)
+		var urls []string
+		for _, save := range filtered {
+			urls = append(urls, save.URL)
+		}
+		if !reflect.DeepEqual(urls, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, urls)
+		}
+	}
+}
+
+func TestFilterSaves_Status(t *testing.T) {
+	mockPocketServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"list": {
+				"1": {"resolved_title": "Unread", "resolved_url": "https://example.com/1", "status": "0"},
+				"2": {"resolved_title": "Archived", "resolved_url": "https://example.com/2", "status": "1"},
+				"3": {"resolved_title": "Deleted", "resolved_url": "https://example.com/3", "status": "2"}
+			}
+		}`))
+	}))
+	defer mockPocketServer.Close()
+
+	originalEndpoint := api.Origin
+	api.Origin = mockPocketServer.URL
+	defer func() { api.Origin = originalEndpoint }()
+
+	saves, err := getRecentPocketSaves(context.Background(), &pocketFetcher{consumerKey: "test_consumer_key", accessToken: "test_access_token", urlSource: urlSourceResolved, titleSource: titleSourceAuto, count: 10}, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("getRecentPocketSaves failed: %v", err)
+	}
+	statuses := map[string]int{}
+	for _, save := range saves {
+		statuses[save.Title] = save.Status
+	}
+	if statuses["Unread"] != 0 || statuses["Archived"] != 1 || statuses["Deleted"] != 2 {
+		t.Errorf("Expected statuses 0, 1 and 2, got %v", statuses)
+	}
+
+	filtered := filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost})
+	if len(filtered) != 1 || filtered[0].Title != "Unread" {
+		t.Errorf("Expected only the unread save, got %+v", filtered)
+	}
+}
+
+func TestFilterSaves_TitleDenyRegex(t *testing.T) {
+	saves := []*PocketItem{
+		{Title: "Login", URL: "https://example.com/paywalled", IsArticle: true},
+		{Title: "Access Denied", URL: "https://example.com/blocked", IsArticle: true},
+		{Title: "Logins considered harmful", URL: "https://example.com/essay", IsArticle: true},
+		{Title: "Generics in Go", URL: "https://example.com/generics", IsArticle: true},
+	}
+
+	config := &Config{ImageItemPolicy: imageItemsPost, TitleDenyRegex: regexp.MustCompile(`^(Login|Access Denied)$`)}
+	var urls []string
+	for _, save := range filterSaves(saves, config) {
+		urls = append(urls, save.URL)
+	}
+	expected := []string{"https://example.com/essay", "https://example.com/generics"}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("Expected %v, got %v", expected, urls)
+	}
+}
+
+func TestFilterSaves_SkipsUntitled(t *testing.T) {
+	mockPageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/og" {
+			w.Write([]byte(`<html><head><meta property="og:title" content="From the Page"></head></html>`))
+			return
+		}
+		w.Write([]byte(`<html><head></head></html>`))
+	}))
+	defer mockPageServer.Close()
+	host := urlHost(mockPageServer.URL)
+
+	saves := []*PocketItem{
+		{ItemID: "1", Title: "Titled", URL: "https://example.com/1", IsArticle: true},
+		{ItemID: "2", Title: "", URL: "https://example.com/2", IsArticle: true},
+		{ItemID: "3", Title: "example.com", URL: "https://example.com/3", IsArticle: true},
+		{ItemID: "4", Title: "", URL: "https://example.com/4.jpg", HasImage: 2},
+		// POCKET2FEDI_OG_FALLBACK runs first and can rescue these
+		{ItemID: "5", Title: host, URL: mockPageServer.URL + "/og", IsArticle: true},
+		{ItemID: "6", Title: host, URL: mockPageServer.URL + "/none", IsArticle: true},
+	}
+
+	prepared := prepareSaves(context.Background(), &Config{ImageItemPolicy: imageItemsPost, OGFallback: true}, nil, saves[4:])
+	saves = append(saves[:4], prepared...)
+	var titles []string
+	for _, save := range filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost}) {
+		titles = append(titles, save.ItemID+":"+save.Title)
+	}
+	expected := []string{"1:Titled", "4:", "5:From the Page"}
+	if !reflect.DeepEqual(titles, expected) {
+		t.Errorf("Expected %v, got %v", expected, titles)
+	}
+}
+
+func TestLoadConfigFromEnv_InvalidRegex(t *testing.T) {
+	os.Setenv("POCKET_CONSUMER_KEY", "test_consumer_key")
+	os.Setenv("POCKET_ACCESS_TOKEN", "test_access_token")
+	os.Setenv("MASTODON_SERVER", "https://mastodon.example")
+	os.Setenv("MASTODON_TOKEN", "test_mastodon_token")
+	defer func() {
+		os.Unsetenv("POCKET_CONSUMER_KEY")
+		os.Unsetenv("POCKET_ACCESS_TOKEN")
+		os.Unsetenv("MASTODON_SERVER")
+		os.Unsetenv("MASTODON_TOKEN")
+	}()
+
+	for _, key := range []string{"URL_REGEX", "TITLE_REGEX", "POCKET_TITLE_DENY_REGEX"} {
+		os.Setenv(key, "([a-z")
+		_, err := LoadConfigFromEnv()
+		if err == nil {
+			t.Errorf("LoadConfigFromEnv should have rejected an invalid %s", key)
+		} else if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected error to mention %s, got: %v", key, err)
+		}
+		os.Unsetenv(key)
+	}
+}
+
+func TestPostSaves_MaintenanceModeDefersRun(t *testing.T) {
+	requests := 0
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token"}
+	saves := []*PocketItem{
+		{Title: "Test Article 1", URL: "https://example.com/article1"},
+		{Title: "Test Article 2", URL: "https://example.com/article2"},
+		{Title: "Test Article 3", URL: "https://example.com/article3"},
+	}
+
+	_, _, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, saves)
+	if !errors.Is(err, errInstanceMaintenance) {
+		t.Fatalf("Expected errInstanceMaintenance, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected the run to stop after 1 request, got %d", requests)
+	}
+}
+
+func TestPostToMastodon_ReadOnly(t *testing.T) {
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"error": "This action is not allowed while the server is in read-only mode"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	_, err := postToMastodon(context.Background(), mockMastodonServer.URL, "test_mastodon_token", &mastodon.Toot{Status: "Test Mastodon post"})
+	if !errors.Is(err, errInstanceMaintenance) {
+		t.Errorf("Expected errInstanceMaintenance, got %v", err)
+	}
+}
+
+func TestPostToMastodon_OtherErrorIsNotMaintenance(t *testing.T) {
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"error": "Validation failed: Text can't be blank"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	_, err := postToMastodon(context.Background(), mockMastodonServer.URL, "test_mastodon_token", &mastodon.Toot{Status: ""})
+	if err == nil || errors.Is(err, errInstanceMaintenance) {
+		t.Errorf("Expected a non-maintenance error, got %v", err)
+	}
+}
+
+func TestPostToMastodon_Duplicate(t *testing.T) {
+	tests := []struct {
+		body      string
+		duplicate bool
+	}{
+		{`{"error": "Duplicate status"}`, true},
+		{`{"error": "Validation failed: Text can't be blank"}`, false},
+	}
+
+	for _, tt := range tests {
+		mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(tt.body))
+		}))
+
+		_, err := postToMastodon(context.Background(), mockMastodonServer.URL, "test_mastodon_token", &mastodon.Toot{Status: "Test Mastodon post"})
+		if err == nil || errors.Is(err, errDuplicateStatus) != tt.duplicate {
+			t.Errorf("%s: expected duplicate %v, got %v", tt.body, tt.duplicate, err)
+		}
+		mockMastodonServer.Close()
+	}
+}
+
+func TestPostSaves_DuplicateIsNotAFailure(t *testing.T) {
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if strings.Contains(r.PostForm.Get("status"), "Old Article") {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"error": "Duplicate status"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	store := newMemoryStateStore()
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Output: outputMastodon, StatusTemplate: defaultStatusTemplate}
+	posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, store, 0, []*PocketItem{
+		{ItemID: "1", Title: "Old Article", URL: "https://example.com/1"},
+		{ItemID: "2", Title: "New Article", URL: "https://example.com/2"},
+	})
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+
+	if posted != 1 || len(errs) != 0 {
+		t.Errorf("Expected 1 post and no failures, got %d posted and %v", posted, errs)
+	}
+	if !store.Posted("1") || !store.Posted("2") {
+		t.Errorf("Expected both saves to be recorded as posted")
+	}
+}
+
+func TestCheckMastodonHealth(t *testing.T) {
+	healthy := true
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			t.Errorf("Unexpected health check path '%s'", r.URL.Path)
+		}
+		if healthy {
+			w.Write([]byte("OK"))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockMastodonServer.Close()
+
+	if err := checkMastodonHealth(context.Background(), mockMastodonServer.URL); err != nil {
+		t.Errorf("checkMastodonHealth failed on a healthy instance: %v", err)
+	}
+
+	healthy = false
+	if err := checkMastodonHealth(context.Background(), mockMastodonServer.URL); !errors.Is(err, errInstanceMaintenance) {
+		t.Errorf("Expected errInstanceMaintenance, got %v", err)
+	}
+}
+
+func TestPostToMastodon_Poll(t *testing.T) {
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		options := r.PostForm["poll[options][]"]
+		if !reflect.DeepEqual(options, []string{"👍", "👎"}) {
+			t.Errorf("Expected poll options [👍 👎], got %v", options)
+		}
+		if expiry := r.PostForm.Get("poll[expires_in]"); expiry != "86400" {
+			t.Errorf("Expected poll expiry '86400', got '%s'", expiry)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	poll, err := parsePoll("", "")
+	if err != nil {
+		t.Fatalf("parsePoll failed: %v", err)
+	}
+
+	_, err = postToMastodon(context.Background(), mockMastodonServer.URL, "test_mastodon_token", &mastodon.Toot{Status: "Test Mastodon post", Poll: poll})
+	if err != nil {
+		t.Errorf("postToMastodon failed: %v", err)
+	}
+}
+
+func TestParsePoll_Constraints(t *testing.T) {
+	poll, err := parsePoll(" Yes , No ,Later", "1h")
+	if err != nil {
+		t.Fatalf("parsePoll failed: %v", err)
+	}
+	if !reflect.DeepEqual(poll.Options, []string{"Yes", "No", "Later"}) {
+		t.Errorf("Expected options [Yes No Later], got %v", poll.Options)
+	}
+	if poll.ExpiresInSeconds != 3600 {
+		t.Errorf("Expected expiry 3600, got %d", poll.ExpiresInSeconds)
+	}
+
+	invalid := []struct {
+		options string
+		expiry  string
+	}{
+		{"Only one", "1h"},
+		{"a,b,c,d,e", "1h"},
+		{"Yes," + strings.Repeat("n", 51), "1h"},
+		{"Yes,No", "1m"},
+		{"Yes,No", "1000h"},
+		{"Yes,No", "soon"},
+	}
+	for _, tt := range invalid {
+		poll, err := parsePoll(tt.options, tt.expiry)
+		if err == nil && len(validatePoll(poll)) == 0 {
+			t.Errorf("Poll %q expiring in %q should have been rejected", tt.options, tt.expiry)
+		}
+	}
+}
+
+func TestPostFailureSummary_OnlyOnFailures(t *testing.T) {
+	var summaries []url.Values
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if strings.Contains(r.PostForm.Get("status"), "Broken Article") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if strings.HasPrefix(r.PostForm.Get("status"), "pocket2fedi run finished") {
+			summaries = append(summaries, r.PostForm)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{
+		MastodonServer: mockMastodonServer.URL,
+		MastodonToken:  "test_mastodon_token",
+		FailureSummary: "direct",
+	}
+
+	// Everything succeeds: no summary
+	posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, []*PocketItem{
+		{Title: "Test Article 1", URL: "https://example.com/article1"},
+	})
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+	if err := postFailureSummary(context.Background(), config, posted, len(errs)); err != nil {
+		t.Fatalf("postFailureSummary failed: %v", err)
+	}
+	if len(summaries) != 0 {
+		t.Fatalf("Expected no summary when nothing failed, got %d", len(summaries))
+	}
+
+	// One failure: a summary is posted with the configured visibility
+	posted, errs, err = postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, []*PocketItem{
+		{Title: "Test Article 2", URL: "https://example.com/article2"},
+		{Title: "Broken Article", URL: "https://example.com/broken"},
+	})
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+	if posted != 1 || len(errs) != 1 {
+		t.Errorf("Expected 1 posted and 1 failed, got %d posted and %d failed", posted, len(errs))
+	}
+	if err := postFailureSummary(context.Background(), config, posted, len(errs)); err != nil {
+		t.Fatalf("postFailureSummary failed: %v", err)
+	}
+	if len(summaries) != 1 {
+		t.Fatalf("Expected 1 summary, got %d", len(summaries))
+	}
+	if summaries[0].Get("visibility") != "direct" {
+		t.Errorf("Expected summary visibility 'direct', got '%s'", summaries[0].Get("visibility"))
+	}
+	if !strings.Contains(summaries[0].Get("status"), "1 posted, 1 failed") {
+		t.Errorf("Expected summary to report counts, got '%s'", summaries[0].Get("status"))
+	}
+}
+
+func TestPrepareSaves_NormalizeUnicode(t *testing.T) {
+	decomposed := "Cafe\u0301 re\u0301sume\u0301"
+	composed := "Caf\u00e9 r\u00e9sum\u00e9"
+
+	saves := []*PocketItem{{Title: decomposed, Excerpt: decomposed, URL: "https://example.com/cafe", IsArticle: true}}
+	prepared := prepareSaves(context.Background(), &Config{NormalizeUnicode: true}, nil, saves)
+	if prepared[0].Title != composed {
+		t.Errorf("Expected composed title %q, got %q", composed, prepared[0].Title)
+	}
+	if prepared[0].Excerpt != composed {
+		t.Errorf("Expected composed excerpt %q, got %q", composed, prepared[0].Excerpt)
+	}
+	if runes := len([]rune(prepared[0].Title)); runes != 11 {
+		t.Errorf("Expected 11 runes after normalization, got %d", runes)
+	}
+
+	saves = []*PocketItem{{Title: decomposed, Excerpt: decomposed, URL: "https://example.com/cafe", IsArticle: true}}
+	prepared = prepareSaves(context.Background(), &Config{NormalizeUnicode: false}, nil, saves)
+	if prepared[0].Title != decomposed {
+		t.Errorf("Expected title to be left alone with normalization off, got %q", prepared[0].Title)
+	}
+	if prepared[0].Excerpt != decomposed {
+		t.Errorf("Expected excerpt to be left alone with normalization off, got %q", prepared[0].Excerpt)
+	}
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if strings.Contains(r.PostForm.Get("status"), "Broken Article") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token"}
+	const nothingNewCode = 3
+
+	tests := []struct {
+		name     string
+		fetcher  *FakeSource
+		expected int
+	}{
+		{"posted", &FakeSource{Items: []*PocketItem{{Title: "Test Article", URL: "https://example.com/article", IsArticle: true}}}, ExitSuccess},
+		{"nothing new", &FakeSource{}, nothingNewCode},
+		{"post failed", &FakeSource{Items: []*PocketItem{{Title: "Broken Article", URL: "https://example.com/broken", IsArticle: true}}}, ExitFailure},
+		{"fetch failed", &FakeSource{Err: errors.New("Pocket unavailable")}, ExitFailure},
+	}
+
+	for _, tt := range tests {
+		result := run(context.Background(), config, tt.fetcher, newTestPoster(t, config), nil, nil)
+		if code := result.ExitCode(nothingNewCode); code != tt.expected {
+			t.Errorf("%s: expected exit code %d, got %d (result %+v)", tt.name, tt.expected, code, result)
+		}
+	}
+
+	// By default nothing new counts as success
+	if code := (Result{}).ExitCode(ExitSuccess); code != ExitSuccess {
+		t.Errorf("Expected nothing new to exit %d by default, got %d", ExitSuccess, code)
+	}
+}
+
+func TestRun_CollectsItemErrors(t *testing.T) {
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if strings.Contains(r.PostForm.Get("status"), "Broken") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token"}
+	fetcher := &FakeSource{Items: []*PocketItem{
+		{Title: "First Article", URL: "https://example.com/first", IsArticle: true},
+		{Title: "Broken One", URL: "https://example.com/broken1", IsArticle: true},
+		{Title: "Second Article", URL: "https://example.com/second", IsArticle: true},
+		{Title: "Broken Two", URL: "https://example.com/broken2", IsArticle: true},
+	}}
+
+	result := run(context.Background(), config, fetcher, newTestPoster(t, config), nil, nil)
+	if result.Err != nil {
+		t.Fatalf("Expected the run to finish, got %v", result.Err)
+	}
+	if result.Posted != 2 {
+		t.Errorf("Expected 2 posted, got %d", result.Posted)
+	}
+	if len(result.Errs) != 2 {
+		t.Fatalf("Expected 2 item errors, got %d: %v", len(result.Errs), result.Errs)
+	}
+	for i, title := range []string{"Broken One", "Broken Two"} {
+		if !strings.Contains(result.Errs[i].Error(), title) {
+			t.Errorf("Expected error %d to name '%s', got %v", i+1, title, result.Errs[i])
+		}
+	}
+	if code := result.ExitCode(ExitSuccess); code != ExitFailure {
+		t.Errorf("Expected exit code %d, got %d", ExitFailure, code)
+	}
+}
+
+func TestRun_MinBatch(t *testing.T) {
+	var posts int
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	save := func(title string, age time.Duration) *PocketItem {
+		return &PocketItem{Title: title, URL: "https://example.com/" + title, IsArticle: true, TimeAdded: time.Now().Add(-age)}
+	}
+
+	tests := []struct {
+		name     string
+		maxHold  time.Duration
+		items    []*PocketItem
+		expected int
+	}{
+		{"below threshold", 0, []*PocketItem{save("a", time.Hour), save("b", time.Hour)}, 0},
+		{"at threshold", 0, []*PocketItem{save("a", time.Hour), save("b", time.Hour), save("c", time.Hour)}, 3},
+		{"above threshold", 0, []*PocketItem{save("a", 0), save("b", 0), save("c", 0), save("d", 0)}, 4},
+		{"held too long", 2 * time.Hour, []*PocketItem{save("a", 3*time.Hour)}, 1},
+		{"within max hold", 2 * time.Hour, []*PocketItem{save("a", time.Hour)}, 0},
+	}
+
+	for _, tt := range tests {
+		posts = 0
+		config := &Config{
+			MastodonServer:  mockMastodonServer.URL,
+			MastodonToken:   "test_mastodon_token",
+			MinBatch:        3,
+			MinBatchMaxHold: tt.maxHold,
+		}
+		result := run(context.Background(), config, &FakeSource{Items: tt.items}, newTestPoster(t, config), nil, nil)
+		if result.Posted != tt.expected || posts != tt.expected {
+			t.Errorf("%s: expected %d posted, got %d (%d requests)", tt.name, tt.expected, result.Posted, posts)
+		}
+	}
+}
+
+func TestPostSaves_DryRun(t *testing.T) {
+	var requests int
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", DryRun: true}
+	posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, []*PocketItem{
+		{Title: "Test Article 1", URL: "https://example.com/article1"},
+		{Title: "Test Article 2", URL: "https://example.com/article2"},
+	})
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("Expected Mastodon never to be called in dry-run mode, got %d requests", requests)
+	}
+	if posted != 0 || len(errs) != 0 {
+		t.Errorf("Expected nothing posted or failed, got %d and %d", posted, len(errs))
+	}
+	if !strings.Contains(logs.String(), "[dry-run] would post: New Pocket save: Test Article 2 - https://example.com/article2") {
+		t.Errorf("Expected the dry-run status in the logs, got:\n%s", logs.String())
+	}
+}
+
+func TestPostSaves_SpoilerText(t *testing.T) {
+	spoilers := map[string]string{}
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		spoilers[r.PostForm.Get("status")] = r.PostForm.Get("spoiler_text")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	saves := []*PocketItem{
+		{Title: "Test Article 1", URL: "https://example.com/article1"},
+		{Title: "Test Article 2", URL: "https://example.com/article2", Tags: []string{"news", "cw:politics"}},
+	}
+
+	tests := []struct {
+		global   string
+		expected []string
+	}{
+		{"", []string{"", "politics"}},
+		{"long read", []string{"long read", "politics"}},
+	}
+
+	for _, tt := range tests {
+		config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", SpoilerText: tt.global}
+		if _, _, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, saves); err != nil {
+			t.Fatalf("postSaves failed: %v", err)
+		}
+
+		for i, save := range saves {
+			status := "New Pocket save: " + save.Title + " - " + save.URL
+			if spoilers[status] != tt.expected[i] {
+				t.Errorf("Global CW %q: expected spoiler %q for '%s', got %q", tt.global, tt.expected[i], save.Title, spoilers[status])
+			}
+		}
+	}
+}
+
+func TestPostSaves_Visibility(t *testing.T) {
+	var visibility string
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		visibility = r.PostForm.Get("visibility")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Visibility: "unlisted"}
+	_, _, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, []*PocketItem{{Title: "Test Article", URL: "https://example.com/article"}})
+	if err != nil {
+		t.Fatalf("postSaves failed: %v", err)
+	}
+	if visibility != "unlisted" {
+		t.Errorf("Expected visibility 'unlisted', got '%s'", visibility)
+	}
+}
+
+func TestPostSaves_ThreadMode(t *testing.T) {
+	tests := []struct {
+		threadMode bool
+		expected   []string
+	}{
+		// The third post fails, so the fourth replies to the second
+		{true, []string{"", "1", "2", "2"}},
+		{false, []string{"", "", "", ""}},
+	}
+
+	for _, tt := range tests {
+		var inReplyTo []string
+		mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			inReplyTo = append(inReplyTo, r.PostForm.Get("in_reply_to_id"))
+			if len(inReplyTo) == 3 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{"id": "%d"}`, len(inReplyTo))
+		}))
+
+		config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Output: outputMastodon, StatusTemplate: defaultStatusTemplate, ThreadMode: tt.threadMode}
+		posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, []*PocketItem{
+			{Title: "Test Article 1", URL: "https://example.com/article1"},
+			{Title: "Test Article 2", URL: "https://example.com/article2"},
+			{Title: "Test Article 3", URL: "https://example.com/article3"},
+			{Title: "Test Article 4", URL: "https://example.com/article4"},
+		})
+		mockMastodonServer.Close()
+		if err != nil {
+			t.Fatalf("postSaves failed: %v", err)
+		}
+		if posted != 3 || len(errs) != 1 {
+			t.Errorf("Expected 3 posted and 1 failed, got %d posted and %d failed", posted, len(errs))
+		}
+		if !reflect.DeepEqual(inReplyTo, tt.expected) {
+			t.Errorf("ThreadMode=%v: expected in_reply_to_id %q, got %q", tt.threadMode, tt.expected, inReplyTo)
+		}
+	}
+}
+
+func TestPostToMastodon_ReturnsStatus(t *testing.T) {
+	var inReplyTo string
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		inReplyTo = r.PostForm.Get("in_reply_to_id")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "42"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	status, err := postToMastodon(context.Background(), mockMastodonServer.URL, "test_mastodon_token", &mastodon.Toot{Status: "Test Mastodon post", InReplyToID: "41"})
+	if err != nil {
+		t.Fatalf("postToMastodon failed: %v", err)
+	}
+	if status.ID != "42" {
+		t.Errorf("Expected status ID '42', got '%s'", status.ID)
+	}
+	if inReplyTo != "41" {
+		t.Errorf("Expected in_reply_to_id '41', got '%s'", inReplyTo)
+	}
+}
+
+func TestPostSaves_Concurrency(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, maxInFlight, requests int
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		requests++
+		maxInFlight = max(maxInFlight, inFlight)
+		failing := requests == 2
+		mu.Unlock()
+
+		// Hold each post long enough for the others to pile up
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	var saves []*PocketItem
+	for i := 1; i <= 7; i++ {
+		saves = append(saves, &PocketItem{Title: fmt.Sprintf("Test Article %d", i), URL: fmt.Sprintf("https://example.com/article%d", i)})
+	}
+
+	for _, concurrency := range []int{1, 3} {
+		inFlight, maxInFlight, requests = 0, 0, 0
+		config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Output: outputMastodon, Concurrency: concurrency}
+		posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, saves)
+
+		// A failed post is counted and the rest still go out
+		if err != nil {
+			t.Fatalf("Concurrency %d: postSaves failed: %v", concurrency, err)
+		}
+		if posted != 6 || len(errs) != 1 {
+			t.Errorf("Concurrency %d: expected 6 posted and 1 failed, got %d posted and %d failed", concurrency, posted, len(errs))
+		}
+		if maxInFlight != concurrency {
+			t.Errorf("Concurrency %d: expected at most %d posts in flight, got %d", concurrency, concurrency, maxInFlight)
+		}
+	}
+}
+
+func TestPostSaves_MaxPostsPerRun(t *testing.T) {
+	var mu sync.Mutex
+	var requests int
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		if strings.Contains(r.PostForm.Get("status"), "Test Article 2") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	for _, concurrency := range []int{1, 3} {
+		requests = 0
+		store, err := loadFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		if err != nil {
+			t.Fatalf("loadFileStateStore failed: %v", err)
+		}
+		var saves []*PocketItem
+		for i := 1; i <= 6; i++ {
+			saves = append(saves, &PocketItem{ItemID: fmt.Sprint(i), Title: fmt.Sprintf("Test Article %d", i), URL: fmt.Sprintf("https://example.com/article%d", i)})
+		}
+
+		config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Output: outputMastodon, Concurrency: concurrency, MaxPostsPerRun: 3}
+		posted, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, store, 0, saves)
+		if err != nil {
+			t.Fatalf("Concurrency %d: postSaves failed: %v", concurrency, err)
+		}
+
+		// The failed post doesn't count towards the limit
+		if posted != 3 || len(errs) != 1 {
+			t.Errorf("Concurrency %d: expected 3 posted and 1 failed, got %d posted and %d failed", concurrency, posted, len(errs))
+		}
+		if requests != 4 {
+			t.Errorf("Concurrency %d: expected 4 posts to be attempted, got %d", concurrency, requests)
+		}
+		for _, id := range []string{"5", "6"} {
+			if store.Posted(id) {
+				t.Errorf("Concurrency %d: expected save %s to be left for the next run", concurrency, id)
+			}
+		}
+	}
+}
+
+func TestSortSaves_PostOrder(t *testing.T) {
+	var statuses []string
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		statuses = append(statuses, r.PostForm.Get("status"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	tests := map[string][]string{
+		postOrderNewest: {"Test Article 3", "Test Article 2", "Test Article 1"},
+		postOrderOldest: {"Test Article 1", "Test Article 2", "Test Article 3"},
+	}
+	for order, expected := range tests {
+		statuses = nil
+		base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		saves := []*PocketItem{
+			{ItemID: "2", Title: "Test Article 2", URL: "https://example.com/article2", TimeAdded: base.Add(2 * time.Hour)},
+			{ItemID: "3", Title: "Test Article 3", URL: "https://example.com/article3", TimeAdded: base.Add(3 * time.Hour)},
+			{ItemID: "1", Title: "Test Article 1", URL: "https://example.com/article1", TimeAdded: base.Add(1 * time.Hour)},
+		}
+
+		config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Output: outputMastodon, Concurrency: 1, PostOrder: order}
+		sortSaves(saves, config.PostOrder)
+		if _, _, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, saves); err != nil {
+			t.Fatalf("Order %s: postSaves failed: %v", order, err)
+		}
+
+		if len(statuses) != len(expected) {
+			t.Fatalf("Order %s: expected %d posts, got %d", order, len(expected), len(statuses))
+		}
+		for i, title := range expected {
+			if !strings.Contains(statuses[i], title) {
+				t.Errorf("Order %s: expected post %d to be '%s', got '%s'", order, i+1, title, statuses[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package syndicate
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// defaultStatusTemplate renders statuses the way they have always looked
+const defaultStatusTemplate = "New Pocket save: {{.Title}} - {{.URL}}"
+
+// renderStatus executes tmpl, a text/template with the PocketItem fields
+// such as {{.Title}}, {{.URL}} and {{.Excerpt}}, against item. An empty
+// tmpl uses defaultStatusTemplate.
+func renderStatus(item *PocketItem, tmpl string) (string, error) {
+	if tmpl == "" {
+		tmpl = defaultStatusTemplate
+	}
+
+	t, err := template.New("status").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse status template: %w", err)
+	}
+
+	var status strings.Builder
+	if err := t.Execute(&status, item); err != nil {
+		return "", fmt.Errorf("failed to render status template: %w", err)
+	}
+	return status.String(), nil
+}
+
+// parseDomainTemplates parses one "domain=template" pair per line into a map
+// keyed by lowercased domain, dropping any leading "*." or "." from the
+// domain. Blank lines are ignored.
+func parseDomainTemplates(value string) (map[string]string, error) {
+	templates := map[string]string{}
+	for _, line := range strings.Split(value, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		domain, tmpl, ok := strings.Cut(line, "=")
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		domain = strings.TrimPrefix(strings.TrimPrefix(domain, "*"), ".")
+		if !ok || domain == "" || strings.TrimSpace(tmpl) == "" {
+			return nil, fmt.Errorf("%q must be domain=template", line)
+		}
+		templates[domain] = strings.TrimSpace(tmpl)
+	}
+	return templates, nil
+}
+
+// selectTemplate returns the template for host from templates, or fallback
+// if there is none. A domain's template also applies to its subdomains, and
+// the most specific domain wins, so blog.example.com can differ from
+// example.com.
+func selectTemplate(host string, templates map[string]string, fallback string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for host != "" {
+		if tmpl, ok := templates[host]; ok {
+			return tmpl
+		}
+		_, host, _ = strings.Cut(host, ".")
+	}
+	return fallback
+}
@@ -0,0 +1,136 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderStatus(t *testing.T) {
+	item := &PocketItem{
+		Title:   "Test Article",
+		URL:     "https://example.com/article",
+		Excerpt: "A short summary.",
+	}
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		expected string
+	}{
+		{"default", "", "New Pocket save: Test Article - https://example.com/article"},
+		{"explicit default", defaultStatusTemplate, "New Pocket save: Test Article - https://example.com/article"},
+		{"custom", "📚 {{.Title}}\n\n{{.Excerpt}}\n{{.URL}}", "📚 Test Article\n\nA short summary.\nhttps://example.com/article"},
+		{"conditional", "{{.Title}}{{if .Excerpt}}: {{.Excerpt}}{{end}}", "Test Article: A short summary."},
+	}
+
+	for _, tt := range tests {
+		status, err := renderStatus(item, tt.tmpl)
+		if err != nil {
+			t.Fatalf("%s: renderStatus failed: %v", tt.name, err)
+		}
+		if status != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, status)
+		}
+	}
+}
+
+func TestRenderStatus_Malformed(t *testing.T) {
+	item := &PocketItem{Title: "Test Article", URL: "https://example.com/article"}
+
+	for _, tmpl := range []string{"{{.Title", "{{.Author}}"} {
+		if _, err := renderStatus(item, tmpl); err == nil {
+			t.Errorf("renderStatus should have failed for %q", tmpl)
+		}
+	}
+}
+
+func TestConfigValidate_MalformedTemplate(t *testing.T) {
+	config := &Config{StatusTemplate: "{{.Title} - {{.URL}}"}
+
+	err := config.Validate()
+	if err == nil || !strings.Contains(err.Error(), "POCKET2FEDI_TEMPLATE") {
+		t.Errorf("Expected Validate to reject the template, got %v", err)
+	}
+}
+
+func TestSelectTemplate(t *testing.T) {
+	templates := map[string]string{
+		"example.com":      "Example: {{.Title}} {{.URL}}",
+		"blog.example.com": "New on my blog: {{.Title}} {{.URL}}",
+	}
+
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{"example.com", "Example: {{.Title}} {{.URL}}"},
+		{"EXAMPLE.com.", "Example: {{.Title}} {{.URL}}"},
+		{"www.example.com", "Example: {{.Title}} {{.URL}}"},
+		{"blog.example.com", "New on my blog: {{.Title}} {{.URL}}"},
+		{"www.blog.example.com", "New on my blog: {{.Title}} {{.URL}}"},
+		{"notexample.com", defaultStatusTemplate},
+		{"example.org", defaultStatusTemplate},
+		{"", defaultStatusTemplate},
+	}
+
+	for _, tt := range tests {
+		if got := selectTemplate(tt.host, templates, defaultStatusTemplate); got != tt.expected {
+			t.Errorf("selectTemplate(%q): expected %q, got %q", tt.host, tt.expected, got)
+		}
+	}
+}
+
+func TestParseDomainTemplates(t *testing.T) {
+	templates, err := parseDomainTemplates("Blog.Example.com = New on my blog: {{.Title}} {{.URL}}\n\n*.go.dev={{.Title}} = {{.URL}}\n")
+	if err != nil {
+		t.Fatalf("parseDomainTemplates failed: %v", err)
+	}
+	expected := map[string]string{
+		"blog.example.com": "New on my blog: {{.Title}} {{.URL}}",
+		"go.dev":           "{{.Title}} = {{.URL}}",
+	}
+	if !reflect.DeepEqual(templates, expected) {
+		t.Errorf("Expected %v, got %v", expected, templates)
+	}
+
+	if _, err := parseDomainTemplates("blog.example.com"); err == nil {
+		t.Errorf("Expected an error for a line without a template")
+	}
+}
+
+func TestPostSaves_DomainTemplates(t *testing.T) {
+	var statuses []string
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		statuses = append(statuses, r.PostForm.Get("status"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{
+		MastodonServer:  mockMastodonServer.URL,
+		MastodonToken:   "test_mastodon_token",
+		Output:          outputMastodon,
+		DomainTemplates: map[string]string{"blog.example.com": "New on my blog: {{.Title}} {{.URL}}"},
+	}
+	_, errs, err := postSaves(context.Background(), config, newTestPoster(t, config), nil, nil, nil, 0, []*PocketItem{
+		{Title: "My Post", URL: "https://blog.example.com/my-post"},
+		{Title: "Their Post", URL: "https://example.org/their-post"},
+	})
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("postSaves failed: %v %v", err, errs)
+	}
+
+	expected := []string{
+		"New on my blog: My Post https://blog.example.com/my-post",
+		"New Pocket save: Their Post - https://example.org/their-post",
+	}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("Expected %q, got %q", expected, statuses)
+	}
+}
@@ -0,0 +1,179 @@
+package syndicate
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// statusURLPattern finds the link in a rendered status
+var statusURLPattern = regexp.MustCompile(`https?://\S+`)
+
+// ellipsis marks where a status was cut short
+const ellipsis = "…"
+
+// mastodonURLLength is how many characters Mastodon counts for every link,
+// however long it really is
+const mastodonURLLength = 23
+
+// statusURLLength is how many characters a link counts for against the
+// limit of a fediverseType server, or 0 if it counts as written
+func statusURLLength(fediverseType string) int {
+	switch fediverseType {
+	case "", fediverseMastodon:
+		return mastodonURLLength
+	}
+	return 0
+}
+
+// statusLength is the length of status in runes, counting each URL as
+// urlLength if that is above 0
+func statusLength(status string, urlLength int) int {
+	n := utf8.RuneCountInString(status)
+	if urlLength > 0 {
+		for _, loc := range statusURLPattern.FindAllStringIndex(status, -1) {
+			n += urlLength - utf8.RuneCountInString(status[loc[0]:loc[1]])
+		}
+	}
+	return n
+}
+
+// statusText is a run of text between the URLs of a status. Only content
+// can be cut; the separators around the URLs (e.g. " - ") are kept.
+type statusText struct {
+	lead, content, trail []rune
+	keep                 int
+	cut                  bool
+}
+
+// isWordRune reports whether r belongs to the words of a status rather than
+// the punctuation separating them from a URL
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// truncateStatus shortens status to at most max characters, counting each
+// URL as urlLength (see statusLength). The text around the URLs, such as the
+// title or an excerpt, is cut and ends in an ellipsis, longest first, while
+// the URLs and the separators next to them are kept intact. A status without
+// a URL is cut at the end. A max below 1 means no limit.
+func truncateStatus(status string, max, urlLength int) string {
+	over := statusLength(status, urlLength) - max
+	if max < 1 || over <= 0 {
+		return status
+	}
+
+	locs := statusURLPattern.FindAllStringIndex(status, -1)
+	if locs == nil {
+		runes := []rune(status)
+		return strings.TrimRightFunc(string(runes[:max-1]), unicode.IsSpace) + ellipsis
+	}
+
+	// Split the status into the text before, between and after its URLs
+	texts := make([]*statusText, len(locs)+1)
+	prev := 0
+	for i := range texts {
+		end := len(status)
+		if i < len(locs) {
+			end = locs[i][0]
+		}
+		runes := []rune(status[prev:end])
+		text := &statusText{}
+		if i < len(locs) {
+			sep := len(runes)
+			for sep > 0 && !isWordRune(runes[sep-1]) {
+				sep--
+			}
+			runes, text.trail = runes[:sep], runes[sep:]
+		}
+		if i > 0 {
+			sep := 0
+			for sep < len(runes) && !isWordRune(runes[sep]) {
+				sep++
+			}
+			text.lead, runes = runes[:sep], runes[sep:]
+		}
+		text.content, text.keep = runes, len(runes)
+		texts[i] = text
+		if i < len(locs) {
+			prev = locs[i][1]
+		}
+	}
+
+	// Cut the longest text first, as far as needed, before moving on to the
+	// next. If the texts can't absorb all of the overflow the status stays
+	// too long, but no URL is ever cut.
+	byLength := slices.Clone(texts)
+	slices.SortStableFunc(byLength, func(a, b *statusText) int {
+		return len(b.content) - len(a.content)
+	})
+	for _, text := range byLength {
+		if over <= 0 || len(text.content) == 0 {
+			break
+		}
+		text.cut = true
+		text.keep = len(text.content) - over - utf8.RuneCountInString(ellipsis)
+		if text.keep < 0 {
+			text.keep = 0
+		}
+		over -= len(text.content) - text.keep - utf8.RuneCountInString(ellipsis)
+	}
+
+	var b strings.Builder
+	for i, text := range texts {
+		b.WriteString(string(text.lead))
+		if text.cut {
+			b.WriteString(strings.TrimRightFunc(string(text.content[:text.keep]), unicode.IsSpace) + ellipsis)
+		} else {
+			b.WriteString(string(text.content))
+		}
+		b.WriteString(string(text.trail))
+		if i < len(locs) {
+			b.WriteString(status[locs[i][0]:locs[i][1]])
+		}
+	}
+	return b.String()
+}
+
+// appendSuffix puts suffix on its own line after status, truncating status so
+// the whole fits in max characters without ever cutting the suffix. An empty
+// suffix leaves status as truncateStatus would.
+func appendSuffix(status, suffix string, max, urlLength int) string {
+	if suffix == "" {
+		return truncateStatus(status, max, urlLength)
+	}
+	if max > 0 {
+		max -= statusLength(suffix, urlLength) + 1
+		if max < 1 {
+			max = 1
+		}
+	}
+	return truncateStatus(status, max, urlLength) + "\n" + suffix
+}
+
+// trimExcerpt shortens excerpt to at most max runes, cutting at the last word
+// boundary that fits and ending in an ellipsis. A max below 1 means no limit.
+func trimExcerpt(excerpt string, max int) string {
+	runes := []rune(excerpt)
+	if max < 1 || len(runes) <= max {
+		return excerpt
+	}
+
+	cut := runes[:max-len([]rune(ellipsis))]
+	// Back up to a space unless the next rune starts a new word anyway
+	if !unicode.IsSpace(runes[len(cut)]) {
+		for i := len(cut) - 1; i > 0; i-- {
+			if unicode.IsSpace(cut[i]) {
+				cut = cut[:i]
+				break
+			}
+		}
+	}
+
+	trimmed := strings.TrimRightFunc(string(cut), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+	return trimmed + ellipsis
+}
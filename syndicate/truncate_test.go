@@ -0,0 +1,204 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateStatus(t *testing.T) {
+	longURL := "https://example.com/" + strings.Repeat("a", 40)
+	tests := []struct {
+		status   string
+		max      int
+		expected string
+	}{
+		{"New Pocket save: Short - https://example.com/a", 500, "New Pocket save: Short - https://example.com/a"},
+		{"New Pocket save: Short - https://example.com/a", 0, "New Pocket save: Short - https://example.com/a"},
+		{"New Pocket save: A rather long title - https://example.com/a", 50, "New Pocket save: A rather… - https://example.com/a"},
+		// Multibyte runes count as one character each
+		{"新しい保存: 日本語のとても長いタイトルです - https://example.com/a", 40, "新しい保存: 日本語のとても長… - https://example.com/a"},
+		{"Émoji 🎉🎉🎉🎉🎉 title - https://example.com/a", 36, "Émoji 🎉🎉🎉🎉🎉… - https://example.com/a"},
+		// Text after the URL is kept too
+		{"Title here - https://example.com/a (archived: https://web.archive.org/a)", 68, "Title… - https://example.com/a (archived: https://web.archive.org/a)"},
+		// The URL is never cut, even if that leaves the status too long
+		{"Title - " + longURL, 30, "… - " + longURL},
+		{"No link in this status at all", 10, "No link i…"},
+	}
+
+	for _, tt := range tests {
+		got := truncateStatus(tt.status, tt.max, 0)
+		if got != tt.expected {
+			t.Errorf("truncateStatus(%q, %d): expected '%s', got '%s'", tt.status, tt.max, tt.expected, got)
+		}
+		if tt.max > 0 && utf8.RuneCountInString(got) > tt.max && !strings.Contains(got, longURL) {
+			t.Errorf("truncateStatus(%q, %d): got %d characters", tt.status, tt.max, utf8.RuneCountInString(got))
+		}
+	}
+}
+
+func TestTruncateStatus_AroundURLs(t *testing.T) {
+	longURL := "https://example.com/" + strings.Repeat("a", 40)
+	tests := []struct {
+		name     string
+		status   string
+		max      int
+		expected string
+	}{
+		// Mastodon counts the link as 23 characters however long it is
+		{"a long URL", "A rather long title - " + longURL, 50, "A rather long title - " + longURL},
+		{"a URL-first template", longURL + " A rather long title that goes on", 40, longURL + " A rather long t…"},
+		{"a long excerpt after the URL", "Title - " + longURL + "\n\nAn excerpt that goes on and on about the article", 60, "Title - " + longURL + "\n\nAn excerpt that goes on an…"},
+		// The longest text gives way first
+		{"a title and an excerpt", "A title that goes on - " + longURL + "\n\nAn excerpt that goes on", 60, "A title that goes on - " + longURL + "\n\nAn excerpt…"},
+		{"a title and an excerpt cut", "A title that goes on - " + longURL + "\n\nAn excerpt that goes on", 45, "A title that go… - " + longURL + "\n\n…"},
+	}
+
+	for _, tt := range tests {
+		got := truncateStatus(tt.status, tt.max, mastodonURLLength)
+		if got != tt.expected {
+			t.Errorf("%s: expected '%s', got '%s'", tt.name, tt.expected, got)
+		}
+		if n := statusLength(got, mastodonURLLength); n > tt.max {
+			t.Errorf("%s: expected at most %d characters, got %d", tt.name, tt.max, n)
+		}
+	}
+}
+
+func TestRun_TruncatesToInstanceLimit(t *testing.T) {
+	var status string
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/instance" {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"uri": "mastodon.example", "configuration": {"statuses": {"max_characters": 60}}}`))
+			return
+		}
+		r.ParseForm()
+		status = r.PostForm.Get("status")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Output: outputMastodon, ImageItemPolicy: imageItemsPost}
+	save := &PocketItem{Title: "Ünïcödé" + strings.Repeat(" wörds", 20), URL: "https://example.com/article", IsArticle: true}
+	if result := run(context.Background(), config, &FakeSource{Items: []*PocketItem{save}}, newTestPoster(t, config), nil, nil); result.Posted != 1 {
+		t.Fatalf("Expected 1 post, got %+v", result)
+	}
+
+	if n := statusLength(status, mastodonURLLength); n > 60 {
+		t.Errorf("Expected at most 60 characters, got %d: '%s'", n, status)
+	}
+	if !strings.HasSuffix(status, "… - https://example.com/article") {
+		t.Errorf("Expected a shortened title and the full URL, got '%s'", status)
+	}
+}
+
+func TestAppendSuffix(t *testing.T) {
+	tests := []struct {
+		status   string
+		suffix   string
+		max      int
+		expected string
+	}{
+		{"Short - https://example.com/a", "", 500, "Short - https://example.com/a"},
+		{"Short - https://example.com/a", "🔖 via Pocket", 500, "Short - https://example.com/a\n🔖 via Pocket"},
+		{"Short - https://example.com/a", "🔖 via Pocket", 0, "Short - https://example.com/a\n🔖 via Pocket"},
+		// The title is cut to make room for the suffix and its newline
+		{"A rather long title - https://example.com/a", "🔖 via Pocket", 50, "A rather lon… - https://example.com/a\n🔖 via Pocket"},
+		// The suffix survives even when the URL alone is too long
+		{"Title - https://example.com/a", "🔖 via Pocket", 20, "… - https://example.com/a\n🔖 via Pocket"},
+	}
+
+	for _, tt := range tests {
+		got := appendSuffix(tt.status, tt.suffix, tt.max, 0)
+		if got != tt.expected {
+			t.Errorf("appendSuffix(%q, %q, %d): expected '%s', got '%s'", tt.status, tt.suffix, tt.max, tt.expected, got)
+		}
+	}
+}
+
+func TestRun_StatusSuffix(t *testing.T) {
+	var status string
+	mockMastodonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/instance" {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"uri": "mastodon.example", "configuration": {"statuses": {"max_characters": 60}}}`))
+			return
+		}
+		r.ParseForm()
+		status = r.PostForm.Get("status")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer mockMastodonServer.Close()
+
+	config := &Config{MastodonServer: mockMastodonServer.URL, MastodonToken: "test_mastodon_token", Output: outputMastodon, ImageItemPolicy: imageItemsPost, StatusSuffix: "🔖 via Pocket"}
+	save := &PocketItem{Title: "A title" + strings.Repeat(" that goes on", 10), URL: "https://example.com/article", IsArticle: true}
+	if result := run(context.Background(), config, &FakeSource{Items: []*PocketItem{save}}, newTestPoster(t, config), nil, nil); result.Posted != 1 {
+		t.Fatalf("Expected 1 post, got %+v", result)
+	}
+
+	if n := statusLength(status, mastodonURLLength); n > 60 {
+		t.Errorf("Expected at most 60 characters, got %d: '%s'", n, status)
+	}
+	if !strings.HasSuffix(status, "… - https://example.com/article\n🔖 via Pocket") {
+		t.Errorf("Expected a shortened title, the full URL and the suffix, got '%s'", status)
+	}
+}
+
+func TestTrimExcerpt(t *testing.T) {
+	tests := []struct {
+		excerpt  string
+		max      int
+		expected string
+	}{
+		{"", 20, ""},
+		{"Short enough.", 20, "Short enough."},
+		{"Short enough.", 0, "Short enough."},
+		// Cut at the last space that fits, never mid-word
+		{"The quick brown fox jumps over the lazy dog", 20, "The quick brown fox…"},
+		{"The quick brown fox jumps over the lazy dog", 18, "The quick brown…"},
+		// Trailing punctuation before the cut is dropped
+		{"First clause, second clause", 16, "First clause…"},
+		// A single long word is cut where it has to be
+		{"Supercalifragilisticexpialidocious", 10, "Supercali…"},
+		{"日本語の 文章を ここで 切ります", 10, "日本語の 文章を…"},
+	}
+
+	for _, tt := range tests {
+		got := trimExcerpt(tt.excerpt, tt.max)
+		if got != tt.expected {
+			t.Errorf("trimExcerpt(%q, %d): expected '%s', got '%s'", tt.excerpt, tt.max, tt.expected, got)
+		}
+		if tt.max > 0 && utf8.RuneCountInString(got) > tt.max {
+			t.Errorf("trimExcerpt(%q, %d): got %d characters", tt.excerpt, tt.max, utf8.RuneCountInString(got))
+		}
+	}
+}
+
+func TestPrepareSaves_MaxExcerptLength(t *testing.T) {
+	saves := []*PocketItem{
+		{Title: "Test Article 1", URL: "https://example.com/article1", IsArticle: true, Excerpt: "A long excerpt that needs trimming"},
+		{Title: "Test Article 2", URL: "https://example.com/article2", IsArticle: true},
+	}
+
+	prepared := prepareSaves(context.Background(), &Config{ImageItemPolicy: imageItemsPost, MaxExcerptLength: 15}, nil, saves)
+	if prepared[0].Excerpt != "A long excerpt…" {
+		t.Errorf("Expected a trimmed excerpt, got '%s'", prepared[0].Excerpt)
+	}
+	if prepared[1].Excerpt != "" {
+		t.Errorf("Expected an empty excerpt to stay empty, got '%s'", prepared[1].Excerpt)
+	}
+
+	status, err := renderStatus(prepared[0], "{{.Title}}: {{.Excerpt}} {{.URL}}")
+	if err != nil {
+		t.Fatalf("renderStatus failed: %v", err)
+	}
+	if status != "Test Article 1: A long excerpt… https://example.com/article1" {
+		t.Errorf("Expected the trimmed excerpt in the status, got '%s'", status)
+	}
+}
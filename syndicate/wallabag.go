@@ -0,0 +1,154 @@
+package syndicate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// wallabagFetcher fetches unread entries from a Wallabag server. It is the
+// reference implementation for adding read-later sources other than Pocket.
+type wallabagFetcher struct {
+	server       string
+	clientID     string
+	clientSecret string
+	username     string
+	password     string
+	urlSource    string
+	count        int
+}
+
+// wallabagEntry is the subset of a Wallabag entry that we use
+type wallabagEntry struct {
+	ID         int    `json:"id"`
+	Title      string `json:"title"`
+	URL        string `json:"url"`
+	GivenURL   string `json:"given_url"`
+	IsArchived int    `json:"is_archived"`
+	Mimetype   string `json:"mimetype"`
+	CreatedAt  string `json:"created_at"`
+	Tags       []struct {
+		Label string `json:"label"`
+	} `json:"tags"`
+}
+
+// wallabagTimeLayout is how Wallabag formats timestamps, e.g.
+// 2024-01-01T10:00:00+0100
+const wallabagTimeLayout = "2006-01-02T15:04:05-0700"
+
+// Fetch returns the count most recent unread Wallabag entries, limited to
+// those changed after since when it is set
+func (f *wallabagFetcher) Fetch(ctx context.Context, since time.Time) ([]*PocketItem, error) {
+	client := httpClient()
+
+	token, err := f.accessToken(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"archive": {"0"},
+		"sort":    {"created"},
+		"order":   {"desc"},
+		"perPage": {strconv.Itoa(f.count)},
+	}
+	if !since.IsZero() {
+		query.Set("since", strconv.FormatInt(since.Unix(), 10))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(f.server, "/")+"/api/entries.json?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Wallabag request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve Wallabag entries: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to retrieve Wallabag entries: status %d", resp.StatusCode)
+	}
+
+	var output struct {
+		Embedded struct {
+			Items []wallabagEntry `json:"items"`
+		} `json:"_embedded"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&output); err != nil {
+		return nil, fmt.Errorf("failed to decode Wallabag entries: %w", err)
+	}
+
+	var recentSaves []*PocketItem
+	for _, entry := range output.Embedded.Items {
+		item := &PocketItem{
+			ItemID:      strconv.Itoa(entry.ID),
+			Title:       entry.Title,
+			GivenURL:    entry.GivenURL,
+			ResolvedURL: entry.URL,
+			IsArticle:   true,
+			Status:      entry.IsArchived,
+		}
+		if !item.chooseURL(f.urlSource) {
+			logger.Debug(fmt.Sprintf("Skipping Wallabag entry %d: it has no %s URL", entry.ID, f.urlSource), "item_id", strconv.Itoa(entry.ID))
+			continue
+		}
+		for _, tag := range entry.Tags {
+			item.Tags = append(item.Tags, tag.Label)
+		}
+		if createdAt, err := time.Parse(wallabagTimeLayout, entry.CreatedAt); err == nil {
+			item.TimeAdded = createdAt
+		}
+		if strings.HasPrefix(entry.Mimetype, "image/") {
+			item.IsArticle = false
+			item.HasImage = 2
+		}
+		recentSaves = append(recentSaves, item)
+	}
+
+	logger.Info(fmt.Sprintf("Successfully retrieved %d recent Wallabag entries", len(recentSaves)), "count", len(recentSaves))
+	return recentSaves, nil
+}
+
+// accessToken exchanges the configured credentials for an OAuth access token
+func (f *wallabagFetcher) accessToken(ctx context.Context, client *http.Client) (string, error) {
+	form := url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {f.clientID},
+		"client_secret": {f.clientSecret},
+		"username":      {f.username},
+		"password":      {f.password},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(f.server, "/")+"/oauth/v2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create Wallabag token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to authenticate with Wallabag: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to authenticate with Wallabag: status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode Wallabag token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("Wallabag did not return an access token")
+	}
+	return token.AccessToken, nil
+}
@@ -0,0 +1,98 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWallabagFetcher_Success(t *testing.T) {
+	mockWallabagServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/v2/token":
+			r.ParseForm()
+			if r.PostForm.Get("grant_type") != "password" || r.PostForm.Get("username") != "reader" {
+				t.Errorf("Unexpected token request: %v", r.PostForm)
+			}
+			w.Write([]byte(`{"access_token": "test_wallabag_token", "token_type": "bearer"}`))
+		case "/api/entries.json":
+			if r.Header.Get("Authorization") != "Bearer test_wallabag_token" {
+				t.Errorf("Expected bearer token, got '%s'", r.Header.Get("Authorization"))
+			}
+			if r.URL.Query().Get("archive") != "0" {
+				t.Errorf("Expected unread entries to be requested, got '%s'", r.URL.RawQuery)
+			}
+			if r.URL.Query().Get("perPage") != "25" {
+				t.Errorf("Expected 25 entries per page, got '%s'", r.URL.Query().Get("perPage"))
+			}
+			w.Write([]byte(`{
+				"_embedded": {
+					"items": [
+						{"id": 1, "title": "Test Article 1", "url": "https://example.com/article1", "given_url": "https://example.com/article1?utm_source=feed", "is_archived": 0, "mimetype": "text/html", "created_at": "2024-01-01T10:00:00+0100", "tags": [{"label": "cw:politics"}]},
+						{"id": 2, "title": "Test Article 2", "url": "https://example.com/article2", "is_archived": 1, "mimetype": "text/html"},
+						{"id": 3, "title": "", "url": "https://example.com/photo.jpg", "is_archived": 0, "mimetype": "image/jpeg"}
+					]
+				}
+			}`))
+		default:
+			t.Errorf("Unexpected request path '%s'", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockWallabagServer.Close()
+
+	fetcher := &wallabagFetcher{
+		server:       mockWallabagServer.URL,
+		clientID:     "test_client_id",
+		clientSecret: "test_client_secret",
+		username:     "reader",
+		password:     "test_password",
+		urlSource:    urlSourceResolved,
+		count:        25,
+	}
+
+	saves, err := fetcher.Fetch(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	if len(saves) != 3 || saves[1].Status != 1 {
+		t.Fatalf("Expected 3 entries with the second archived, got %+v", saves)
+	}
+	saves = filterSaves(saves, &Config{ImageItemPolicy: imageItemsPost})
+	if len(saves) != 2 {
+		t.Fatalf("Expected 2 unread entries, got %d", len(saves))
+	}
+	if saves[0].Title != "Test Article 1" || saves[0].URL != "https://example.com/article1" {
+		t.Errorf("Unexpected first entry: %+v", saves[0])
+	}
+	if expected := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC); !saves[0].TimeAdded.Equal(expected) {
+		t.Errorf("Expected first entry added at %v, got %v", expected, saves[0].TimeAdded)
+	}
+	if len(saves[0].Tags) != 1 || saves[0].Tags[0] != "cw:politics" {
+		t.Errorf("Expected tags [cw:politics], got %v", saves[0].Tags)
+	}
+	if saves[0].GivenURL != "https://example.com/article1?utm_source=feed" {
+		t.Errorf("Expected given URL to be kept, got '%s'", saves[0].GivenURL)
+	}
+	if !saves[1].isImage() {
+		t.Errorf("Expected the image entry to be recognized as an image")
+	}
+}
+
+func TestWallabagFetcher_AuthFailure(t *testing.T) {
+	mockWallabagServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "invalid_grant"}`))
+	}))
+	defer mockWallabagServer.Close()
+
+	fetcher := &wallabagFetcher{server: mockWallabagServer.URL}
+
+	_, err := fetcher.Fetch(context.Background(), time.Time{})
+	if err == nil {
+		t.Errorf("Fetch should have failed")
+	}
+}
@@ -0,0 +1,66 @@
+package syndicate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Wayback modes selecting which link is posted once a save is archived
+const (
+	waybackOriginal = "original"
+	waybackArchive  = "archive"
+	waybackBoth     = "both"
+)
+
+// waybackEndpoint is the Wayback Machine origin, overridable in tests
+var waybackEndpoint = "https://web.archive.org"
+
+// waybackTimeout bounds how long we wait for the save API, which is often slow
+var waybackTimeout = 30 * time.Second
+
+// archiveToWayback submits a URL to the Wayback Machine save API and returns
+// the URL of the archived snapshot. Requests are paced by limiter.
+func archiveToWayback(ctx context.Context, limiter *fetchLimiter, rawURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, waybackTimeout)
+	defer cancel()
+
+	endpoint, err := url.Parse(waybackEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid Wayback endpoint: %w", err)
+	}
+	release, err := limiter.acquire(ctx, endpoint.Host)
+	if err != nil {
+		return "", fmt.Errorf("gave up waiting to contact Wayback Machine: %w", err)
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, waybackEndpoint+"/save/"+rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Wayback request: %w", err)
+	}
+
+	resp, err := httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach Wayback Machine: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Wayback Machine returned status %d", resp.StatusCode)
+	}
+
+	// The save API reports the snapshot path in Content-Location; when it is
+	// missing we may have been redirected straight to the snapshot instead
+	if location := resp.Header.Get("Content-Location"); strings.HasPrefix(location, "/web/") {
+		return waybackEndpoint + location, nil
+	}
+	if strings.HasPrefix(resp.Request.URL.Path, "/web/") {
+		return resp.Request.URL.String(), nil
+	}
+
+	return "", fmt.Errorf("Wayback Machine response did not include a snapshot location")
+}
@@ -0,0 +1,95 @@
+package syndicate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestArchiveToWayback_Success(t *testing.T) {
+	mockWaybackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/save/https://example.com/article1" {
+			t.Errorf("Unexpected Wayback request path '%s'", r.URL.Path)
+		}
+		w.Header().Set("Content-Location", "/web/20240101000000/https://example.com/article1")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockWaybackServer.Close()
+
+	originalEndpoint := waybackEndpoint
+	waybackEndpoint = mockWaybackServer.URL
+	defer func() { waybackEndpoint = originalEndpoint }()
+
+	archiveURL, err := archiveToWayback(context.Background(), nil, "https://example.com/article1")
+	if err != nil {
+		t.Fatalf("archiveToWayback failed: %v", err)
+	}
+
+	expected := mockWaybackServer.URL + "/web/20240101000000/https://example.com/article1"
+	if archiveURL != expected {
+		t.Errorf("Expected archive URL '%s', got '%s'", expected, archiveURL)
+	}
+}
+
+func TestArchiveToWayback_Unavailable(t *testing.T) {
+	mockWaybackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockWaybackServer.Close()
+
+	originalEndpoint := waybackEndpoint
+	waybackEndpoint = mockWaybackServer.URL
+	defer func() { waybackEndpoint = originalEndpoint }()
+
+	_, err := archiveToWayback(context.Background(), nil, "https://example.com/article1")
+	if err == nil {
+		t.Errorf("archiveToWayback should have failed")
+	}
+}
+
+func TestArchiveToWayback_Slow(t *testing.T) {
+	mockWaybackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockWaybackServer.Close()
+
+	originalEndpoint, originalTimeout := waybackEndpoint, waybackTimeout
+	waybackEndpoint, waybackTimeout = mockWaybackServer.URL, 50*time.Millisecond
+	defer func() { waybackEndpoint, waybackTimeout = originalEndpoint, originalTimeout }()
+
+	_, err := archiveToWayback(context.Background(), nil, "https://example.com/article1")
+	if err == nil {
+		t.Errorf("archiveToWayback should have timed out")
+	}
+}
+
+func TestFormatStatus_WaybackModes(t *testing.T) {
+	save := &PocketItem{Title: "Test Article", URL: "https://example.com/article"}
+	archiveURL := "https://web.archive.org/web/20240101000000/https://example.com/article"
+
+	tests := []struct {
+		mode       string
+		archiveURL string
+		expected   string
+	}{
+		{"", "", "New Pocket save: Test Article - https://example.com/article"},
+		{waybackOriginal, archiveURL, "New Pocket save: Test Article - https://example.com/article"},
+		{waybackArchive, archiveURL, "New Pocket save: Test Article - " + archiveURL},
+		{waybackBoth, archiveURL, "New Pocket save: Test Article - https://example.com/article (archived: " + archiveURL + ")"},
+		// Falls back to the original link when archiving failed
+		{waybackArchive, "", "New Pocket save: Test Article - https://example.com/article"},
+	}
+
+	for _, tt := range tests {
+		status, err := formatStatus(save, tt.archiveURL, tt.mode, "")
+		if err != nil {
+			t.Fatalf("formatStatus failed: %v", err)
+		}
+		if status != tt.expected {
+			t.Errorf("Mode '%s': expected '%s', got '%s'", tt.mode, tt.expected, status)
+		}
+	}
+}